@@ -2,14 +2,18 @@ package app
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 
@@ -21,9 +25,15 @@ import (
 	"github.com/tdh8316/Investigo/internal/scan"
 )
 
-func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
-	fmt.Fprintln(stdout, "Investigo - Investigate Users Across Social Networks.")
+// exitInterrupted is returned when the run was cancelled before every
+// username was scanned; the outputs written so far are partial.
+const exitInterrupted = 3
+
+// checkpointFile records completed results inside the results directory
+// until the run finishes.
+const checkpointFile = ".checkpoint.ndjson"
 
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
 	opts, usernames, err := cli.Parse(args, stdout, stderr)
 	if err != nil {
 		if errors.Is(err, cli.ErrHelp) {
@@ -33,8 +43,23 @@ func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
 		return 2
 	}
 
+	// With a machine-readable format, stdout carries only records;
+	// status messages go (uncolored) to stderr.
+	records := stdout
+	machine := opts.Format != cli.FormatText
+	if machine {
+		stdout = stderr
+		opts.NoColor = true
+	}
+
 	color.NoColor = opts.NoColor
 
+	fmt.Fprintln(stdout, cli.Banner)
+
+	if opts.CheckDatabase {
+		return runCheckDatabase(stdout, stderr, opts)
+	}
+
 	httpClient, err := httpx.NewClient(httpx.ClientConfig{
 		Timeout:     opts.Timeout,
 		WithTor:     opts.WithTor,
@@ -46,11 +71,12 @@ func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
 	}
 
 	// Load + optionally update database.
-	sites, err := loadDatabase(ctx, httpClient, opts, stdout)
+	db, err := loadDatabase(ctx, httpClient, opts, stdout)
 	if err != nil {
 		fmt.Fprintf(stderr, "database error: %v\n", err)
 		return 1
 	}
+	sites := db.Sites
 
 	// Optional: filter sites.
 	if len(opts.Sites) > 0 {
@@ -72,6 +98,12 @@ func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
 		}
 	}
 
+	// NSFW sites are excluded from username scans unless requested or named explicitly.
+	// Validation still covers the full database.
+	if !opts.NSFW && !opts.Test {
+		sites = filterNSFW(sites, opts.Sites)
+	}
+
 	// Build scanner once (reuses regex cache + client).
 	scanner := scan.NewScanner(httpClient, scan.Config{
 		UserAgent:    httpx.DefaultUserAgent,
@@ -79,64 +111,351 @@ func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
 		Download:     opts.Download,
 		Concurrency:  opts.Concurrency,
 		MaxBodyBytes: 2 << 20, // 2 MiB max body read for message checks
+
+		DownloadConcurrency: 4,
+		NoFollowRedirects:   opts.NoRedirects,
+		RateLimit:           opts.RateLimit,
+		RateBurst:           opts.RateBurst,
+		Retries:             opts.Retries,
+		RetryBackoff:        opts.RetryBackoff,
 	}, downloaders.Downloaders)
 
 	if opts.Test {
-		return runTest(ctx, stdout, opts.NoColor, scanner, sites)
+		return runTest(ctx, stdout, stderr, opts, scanner, db, sites)
 	}
 
+	var dbChecksum string
+	if opts.HTML && !opts.NoOutput {
+		dbChecksum, _ = data.Checksum(opts.DataFiles...)
+	}
+
+	summary := output.RunSummary{Started: time.Now()}
+
+	// Every username shares one worker pool; outputs are written per user as
+	// soon as all of that user's sites are done.
+	var users []*userScan
+	seen := make(map[string]*userScan)
 	for _, username := range usernames {
 		username = strings.TrimSpace(username)
-		if username == "" {
+		if username == "" || seen[username] != nil {
 			continue
 		}
+		u := &userScan{username: username, dir: filepath.Join(opts.ResultsDir, username), sites: len(sites)}
+		seen[username] = u
+		users = append(users, u)
+	}
+	if len(users) == 0 {
+		fmt.Fprintln(stderr, "no usernames provided")
+		return 2
+	}
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = u.username
+	}
 
-		// Header (stdout).
-		if opts.NoColor {
-			fmt.Fprintf(stdout, "\nInvestigating %s on:\n", username)
-		} else {
-			fmt.Fprintf(color.Output, "\nInvestigating %s on:\n", color.HiGreenString(username))
+	// Completed results are checkpointed as they stream in so an interrupted
+	// scan can be picked up again with --resume.
+	var checkpoint *output.Checkpoint
+	if !opts.NoOutput {
+		if err := os.MkdirAll(opts.ResultsDir, 0o755); err != nil {
+			fmt.Fprintf(stderr, "failed to create results dir %q: %v\n", opts.ResultsDir, err)
+			return 1
 		}
-
-		userDir := filepath.Join(opts.ResultsDir, username)
-		if err := os.MkdirAll(userDir, 0o755); err != nil {
-			fmt.Fprintf(stderr, "failed to create results dir %q: %v\n", userDir, err)
+		checkpointPath := filepath.Join(opts.ResultsDir, checkpointFile)
+		checkpoint, err = output.OpenCheckpoint(checkpointPath, opts.Resume, names)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to open checkpoint %q: %v\n", checkpointPath, err)
 			return 1
 		}
+		// Progress is dropped only for the usernames this run got through.
+		defer func() {
+			var done []string
+			for _, u := range users {
+				if len(u.results) == u.sites {
+					done = append(done, u.username)
+				}
+			}
+			_ = checkpoint.Remove(done...)
+		}()
+	}
 
-		// Buffer for out.txt content; printer writes results to this buffer and also streams to stdout.
-		var buf strings.Builder
+	// Header (stdout).
+	if opts.NoColor {
+		fmt.Fprintf(stdout, "\nInvestigating %s on:\n", strings.Join(names, ", "))
+	} else {
+		fmt.Fprintf(color.Output, "\nInvestigating %s on:\n", color.HiGreenString(strings.Join(names, ", ")))
+	}
 
-		printer := output.NewPrinter(stdout, opts.NoColor, opts.Verbose, &buf)
+	var recordPrinter *output.JSONPrinter
+	if machine {
+		recordPrinter = output.NewJSONPrinter(records, opts.Format == cli.FormatNDJSON)
+	}
+
+	// Cancelled on a fatal output error so the scan stops early; ctx alone
+	// tells whether the run was interrupted.
+	scanCtx, cancelScan := context.WithCancel(ctx)
+	defer cancelScan()
+	var fatal error
+
+	finish := func(u *userScan) {
+		if u.finished || fatal != nil {
+			return
+		}
+		u.finished = true
+		if err := writeUserOutputs(opts, machine, dbChecksum, u); err != nil {
+			fatal = err
+			cancelScan()
+		}
+	}
+
+	var targets []scan.Target
+	for _, u := range users {
+		if err := os.MkdirAll(u.dir, 0o755); err != nil {
+			fmt.Fprintf(stderr, "failed to create results dir %q: %v\n", u.dir, err)
+			return 1
+		}
+
+		stdoutPrinter := stdout
+		if machine {
+			// Records replace the human-readable lines on stdout; out.txt is still written.
+			stdoutPrinter = io.Discard
+		}
+		u.printer = output.NewPrinter(stdoutPrinter, opts.NoColor, opts.Verbose, &u.buf)
+		if len(users) > 1 {
+			u.printer.SetPrefix(u.username + ": ")
+		}
+		u.emit = func(res scan.Result) {
+			u.results = append(u.results, res)
+			u.printer.Result(res)
+			if recordPrinter != nil {
+				recordPrinter.Result(res)
+			}
+		}
 
-		downloadDir := filepath.Join(userDir, "downloads")
+		downloadDir := filepath.Join(u.dir, "downloads")
 		if opts.Download {
 			_ = os.MkdirAll(downloadDir, 0o755)
 		}
 
-		// Stream results as they complete.
-		if err := scanner.ScanUsername(ctx, username, sites, downloadDir, printer.Logger(), printer.Result); err != nil &&
-			!errors.Is(err, context.Canceled) {
-			fmt.Fprintf(stderr, "scan error for %q: %v\n", username, err)
+		// With --resume, replay the checkpointed results and scan only the rest.
+		pending := sites
+		if checkpoint != nil && opts.Resume {
+			var previous []scan.Result
+			previous, pending = resumeFromCheckpoint(checkpoint, u.username, sites)
+			if len(previous) > 0 {
+				fmt.Fprintf(stdout, "%s[Resumed] %d of %d sites from checkpoint\n",
+					u.printer.Logger().Prefix(), len(previous), len(sites))
+			}
+			for _, res := range previous {
+				u.emit(res)
+			}
+		}
+		if len(pending) == 0 {
+			finish(u)
+			continue
 		}
 
-		if !opts.NoOutput {
-			outPath := filepath.Join(userDir, "out.txt")
-			if err := os.WriteFile(outPath, []byte(buf.String()), 0o600); err != nil {
-				fmt.Fprintf(stderr, "failed to write %q: %v\n", outPath, err)
-				return 1
+		targets = append(targets, scan.Target{
+			Username:    u.username,
+			Sites:       pending,
+			DownloadDir: downloadDir,
+			Logger:      u.printer.Logger(),
+		})
+	}
+
+	var checkpointErr error
+	// Stream results as they complete.
+	if err := scanner.ScanUsernames(scanCtx, targets, func(res scan.Result) {
+		// Probes cut short by cancellation aren't results; leave them for --resume.
+		if res.Err != nil && scanCtx.Err() != nil {
+			return
+		}
+		u := seen[res.Username]
+		u.emit(res)
+		// A download that failed because the scan was cancelled is tried again on --resume.
+		cutShort := res.Download != nil && res.Download.Err != nil && scanCtx.Err() != nil
+		if checkpoint != nil && checkpointErr == nil && !cutShort {
+			if checkpointErr = checkpoint.Record(res); checkpointErr != nil {
+				fmt.Fprintf(stderr, "failed to write checkpoint: %v\n", checkpointErr)
 			}
 		}
+		if len(u.results) == u.sites {
+			finish(u)
+		}
+	}); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintf(stderr, "scan error: %v\n", err)
+	}
+
+	// Users cut short by an interruption still get their partial outputs.
+	for _, u := range users {
+		if len(u.results) > 0 {
+			finish(u)
+		}
+	}
+
+	if recordPrinter != nil {
+		if err := recordPrinter.Close(); err != nil && fatal == nil {
+			fatal = fmt.Errorf("failed to write records: %w", err)
+		}
+	}
+	if fatal != nil {
+		fmt.Fprintln(stderr, fatal)
+		return 1
+	}
+
+	for _, u := range users {
+		us := output.NewUserSummary(u.username, u.sites, u.results)
+		if len(u.results) == 0 && us.Status == output.UserIncomplete {
+			us.Status = output.UserNotStarted
+		}
+		summary.Users = append(summary.Users, us)
+	}
+	summary.Finished = time.Now()
+	summary.Interrupted = ctx.Err() != nil
+
+	if !opts.NoOutput {
+		outPath := filepath.Join(opts.ResultsDir, "run-summary.json")
+		if err := output.WriteRunSummary(outPath, summary); err != nil {
+			fmt.Fprintf(stderr, "failed to write %q: %v\n", outPath, err)
+			return 1
+		}
+	}
+
+	if summary.Interrupted {
+		printInterrupted(stdout, opts.NoColor, summary, checkpoint != nil)
+		return exitInterrupted
 	}
 
 	return 0
 }
 
-func loadDatabase(ctx context.Context, client httpx.Doer, opts cli.Options, stdout io.Writer) (map[string]data.SiteData, error) {
-	_, statErr := os.Stat(opts.DataFile)
+// userScan is the per-username state of a run.
+type userScan struct {
+	username string
+	dir      string
+	sites    int
+
+	buf      strings.Builder // out.txt content
+	printer  *output.Printer
+	emit     func(scan.Result)
+	results  []scan.Result
+	finished bool
+}
+
+// writeUserOutputs writes the result files of u, marking them incomplete
+// when not every site was checked.
+func writeUserOutputs(opts cli.Options, machine bool, dbChecksum string, u *userScan) error {
+	if opts.NoOutput {
+		return nil
+	}
+
+	results := slices.Clone(u.results)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Site < results[j].Site })
+	incomplete := len(results) < u.sites
+
+	text := u.buf.String()
+	if incomplete {
+		text = fmt.Sprintf("[!] Incomplete: scan interrupted after %d of %d sites\n", len(results), u.sites) + text
+	}
+	outPath := filepath.Join(u.dir, "out.txt")
+	if err := os.WriteFile(outPath, []byte(text), 0o600); err != nil {
+		return fmt.Errorf("failed to write %q: %w", outPath, err)
+	}
+
+	if machine {
+		outPath = filepath.Join(u.dir, "out."+opts.Format)
+		if err := output.WriteJSON(outPath, opts.Format == cli.FormatNDJSON, results); err != nil {
+			return fmt.Errorf("failed to write %q: %w", outPath, err)
+		}
+	}
+
+	if opts.CSV {
+		outPath = filepath.Join(u.dir, "out.csv")
+		if err := output.WriteCSVFile(outPath, results); err != nil {
+			return fmt.Errorf("failed to write %q: %w", outPath, err)
+		}
+	}
+
+	if opts.HTML {
+		outPath = filepath.Join(u.dir, "report.html")
+		meta := output.ReportMeta{
+			Username:       u.username,
+			Database:       strings.Join(opts.DataFiles, ", "),
+			DatabaseSHA256: dbChecksum,
+			WithTor:        opts.WithTor,
+			Generated:      time.Now(),
+			Incomplete:     incomplete,
+		}
+		if err := output.WriteHTMLReportFile(outPath, meta, results); err != nil {
+			return fmt.Errorf("failed to write %q: %w", outPath, err)
+		}
+	}
+	return nil
+}
+
+// printInterrupted reports how far each username got before cancellation.
+func printInterrupted(stdout io.Writer, noColor bool, summary output.RunSummary, resumable bool) {
+	out := stdout
+	if noColor {
+		fmt.Fprintln(out, "\n[!] Interrupted; results are incomplete")
+	} else {
+		out = color.Output
+		fmt.Fprintf(out, "\n[%s] Interrupted; results are incomplete\n", color.HiRedString("!"))
+	}
+
+	for _, us := range summary.Users {
+		line := fmt.Sprintf("%s: %s", us.Username, us.Status)
+		if us.Status != output.UserNotStarted {
+			line += fmt.Sprintf(" (%d of %d sites, %d found)", us.Scanned, us.Sites, us.Found)
+		}
+		fmt.Fprintf(out, "    %s\n", line)
+	}
+
+	if !resumable {
+		return
+	}
+	if noColor {
+		fmt.Fprintln(out, "[i] Run again with --resume to continue where this run stopped")
+	} else {
+		fmt.Fprintf(out, "[%s] Run again with --resume to continue where this run stopped\n", color.HiBlueString("i"))
+	}
+}
+
+// resumeFromCheckpoint returns the checkpointed results of username for the
+// selected sites, sorted by site, and the sites that still need scanning.
+func resumeFromCheckpoint(
+	checkpoint *output.Checkpoint,
+	username string,
+	sites map[string]data.SiteData,
+) ([]scan.Result, map[string]data.SiteData) {
+	var previous []scan.Result
+	for _, res := range checkpoint.Results(username) {
+		if _, ok := sites[res.Site]; ok {
+			previous = append(previous, res)
+		}
+	}
+	sort.Slice(previous, func(i, j int) bool { return previous[i].Site < previous[j].Site })
+
+	pending := make(map[string]data.SiteData, len(sites)-len(previous))
+	for name, sd := range sites {
+		if !checkpoint.Done(username, name) {
+			pending[name] = sd
+		}
+	}
+	return previous, pending
+}
+
+func loadDatabase(ctx context.Context, client httpx.Doer, opts cli.Options, stdout io.Writer) (*data.Database, error) {
+	// Only the first (upstream) database is refreshed; later ones are local overlays.
+	upstream := opts.DataFiles[0]
+	info, statErr := os.Stat(upstream)
 	fileExists := statErr == nil
 
-	if opts.UpdateBeforeRun || !fileExists {
+	if fileExists && info.IsDir() {
+		if opts.UpdateBeforeRun {
+			return nil, fmt.Errorf("cannot update %q: the first --database must be a file", upstream)
+		}
+	} else if opts.UpdateBeforeRun || !fileExists || isStale(upstream, opts.UpdateIfOlderThan) {
 		if opts.NoColor {
 			fmt.Fprintf(stdout, "[!] Update database: Downloading...")
 		} else {
@@ -146,7 +465,20 @@ func loadDatabase(ctx context.Context, client httpx.Doer, opts cli.Options, stdo
 			)
 		}
 
-		if err := data.UpdateFromRemote(ctx, client, httpx.DefaultUserAgent, opts.DataFile); err != nil {
+		// Keep the current database around to report what the update changed.
+		var previous *data.Database
+		if fileExists {
+			previous, _ = data.Load(upstream)
+		}
+
+		updateCfg := data.UpdateConfig{
+			Source:    opts.UpdateSource,
+			Commit:    opts.UpdateCommit,
+			SHA256:    opts.UpdateSHA256,
+			UserAgent: httpx.DefaultUserAgent,
+		}
+		updated, err := data.Update(ctx, client, updateCfg, upstream)
+		if err != nil {
 			if fileExists {
 				// Fall back to existing database.
 				if opts.NoColor {
@@ -160,16 +492,114 @@ func loadDatabase(ctx context.Context, client httpx.Doer, opts cli.Options, stdo
 			} else {
 				return nil, fmt.Errorf("failed to update database and no existing database found: %w", err)
 			}
+		} else if !updated {
+			if opts.NoColor {
+				fmt.Fprintln(stdout, "[Up to date]")
+			} else {
+				fmt.Fprintf(color.Output, "[%s]\n", color.GreenString("Up to date"))
+			}
 		} else {
 			if opts.NoColor {
 				fmt.Fprintln(stdout, "[Done]")
 			} else {
 				fmt.Fprintf(color.Output, "[%s]\n", color.GreenString("Done"))
 			}
+
+			if current, err := data.Load(upstream); previous != nil && err == nil {
+				changes := data.Diff(previous, current)
+				printChanges(stdout, opts.NoColor, changes)
+				if opts.UpdateChangelog != "" {
+					if err := changes.AppendChangelog(opts.UpdateChangelog, updateCfg.SourceURL(), time.Now()); err != nil {
+						return nil, fmt.Errorf("write changelog: %w", err)
+					}
+				}
+			}
 		}
 	}
 
-	return data.LoadSites(opts.DataFile)
+	return data.LoadMerged(opts.DataFiles...)
+}
+
+// isStale reports whether the database was last updated more than maxAge ago.
+// A zero maxAge disables the check.
+func isStale(path string, maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	last, err := data.LastUpdated(path)
+	return err != nil || time.Since(last) > maxAge
+}
+
+func printChanges(stdout io.Writer, noColor bool, changes data.Changes) {
+	summary := fmt.Sprintf("Database changes: %d added, %d removed, %d modified",
+		len(changes.Added), len(changes.Removed), len(changes.Modified))
+	if noColor {
+		fmt.Fprintf(stdout, "[i] %s\n", summary)
+	} else {
+		fmt.Fprintf(color.Output, "[%s] %s\n", color.HiBlueString("i"), summary)
+	}
+
+	for _, name := range changes.Added {
+		if noColor {
+			fmt.Fprintf(stdout, "    + %s\n", name)
+		} else {
+			fmt.Fprintf(color.Output, "    %s %s\n", color.HiGreenString("+"), name)
+		}
+	}
+	for _, name := range changes.Removed {
+		if noColor {
+			fmt.Fprintf(stdout, "    - %s\n", name)
+		} else {
+			fmt.Fprintf(color.Output, "    %s %s\n", color.HiRedString("-"), name)
+		}
+	}
+	for _, m := range changes.Modified {
+		fields := strings.Join(m.Fields, ", ")
+		if noColor {
+			fmt.Fprintf(stdout, "    ~ %s (%s)\n", m.Site, fields)
+		} else {
+			fmt.Fprintf(color.Output, "    %s %s (%s)\n", color.HiYellowString("~"), m.Site, fields)
+		}
+	}
+}
+
+func runCheckDatabase(stdout, stderr io.Writer, opts cli.Options) int {
+	files, err := data.ExpandPaths(opts.DataFiles...)
+	if err != nil {
+		fmt.Fprintf(stderr, "database error: %v\n", err)
+		return 1
+	}
+
+	total := 0
+	for _, file := range files {
+		problems, err := data.CheckFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", file, err)
+			total++
+			continue
+		}
+		total += len(problems)
+
+		for _, p := range problems {
+			if opts.NoColor {
+				fmt.Fprintf(stdout, "[-] %s: %s\n", file, p)
+			} else {
+				fmt.Fprintf(color.Output, "[%s] %s: %s\n", color.HiRedString("-"), file, p)
+			}
+		}
+	}
+
+	if total > 0 {
+		fmt.Fprintf(stdout, "\nFound %d problem(s) in %d file(s).\n", total, len(files))
+		return 1
+	}
+
+	if opts.NoColor {
+		fmt.Fprintf(stdout, "[+] No problems found in %d file(s).\n", len(files))
+	} else {
+		fmt.Fprintf(color.Output, "[%s] No problems found in %d file(s).\n", color.HiGreenString("+"), len(files))
+	}
+	return 0
 }
 
 func filterSites(all map[string]data.SiteData, selected []string, stdout io.Writer, noColor bool) map[string]data.SiteData {
@@ -226,6 +656,19 @@ func filterSites(all map[string]data.SiteData, selected []string, stdout io.Writ
 	return out
 }
 
+func filterNSFW(all map[string]data.SiteData, keep []string) map[string]data.SiteData {
+	out := make(map[string]data.SiteData, len(all))
+	for name, sd := range all {
+		if sd.IsNSFW && !slices.ContainsFunc(keep, func(s string) bool {
+			return strings.EqualFold(strings.TrimSpace(s), name)
+		}) {
+			continue
+		}
+		out[name] = sd
+	}
+	return out
+}
+
 func promptUsernames(stdout io.Writer, stdin io.Reader) []string {
 	fmt.Fprint(stdout, "Enter usernames to investigate separated by a space: ")
 	r := bufio.NewReader(stdin)
@@ -252,15 +695,38 @@ func printDownloaders(stdout io.Writer, noColor bool) {
 	}
 }
 
-func runTest(ctx context.Context, stdout io.Writer, noColor bool, scanner *scan.Scanner, sites map[string]data.SiteData) int {
+func runTest(
+	ctx context.Context,
+	stdout, stderr io.Writer,
+	opts cli.Options,
+	scanner *scan.Scanner,
+	db *data.Database,
+	sites map[string]data.SiteData,
+) int {
+	noColor := opts.NoColor
 	if noColor {
 		fmt.Fprintln(stdout, "[i] Checking site validity...")
 	} else {
 		fmt.Fprintf(color.Output, "[%s] Checking site validity...\n", color.HiBlueString("i"))
 	}
 
-	failCount, _ := scanner.ValidateSites(ctx, sites, func(f scan.ValidationFailure) {
-		if f.Used.Err != nil || f.Unused.Err != nil {
+	report := &output.HealthReport{
+		Generated: time.Now(),
+		Database:  strings.Join(opts.DataFiles, ", "),
+		WithTor:   opts.WithTor,
+	}
+	report.DatabaseSHA256, _ = data.Checksum(opts.DataFiles...)
+
+	var failing []scan.Validation
+	failCount, _ := scanner.ValidateSites(ctx, sites, func(f scan.Validation) {
+		report.Add(f)
+
+		if f.Health() == scan.HealthPassing {
+			return
+		}
+		failing = append(failing, f)
+
+		if f.Health() == scan.HealthErrored {
 			var msgParts []string
 			if f.Used.Err != nil {
 				msgParts = append(msgParts, "["+f.Used.Err.Error()+"]")
@@ -284,16 +750,16 @@ func runTest(ctx context.Context, stdout io.Writer, noColor bool, scanner *scan.
 
 		if noColor {
 			fmt.Fprintf(stdout,
-				"[-] %s: Not working (%s: expected true, result is %t | %s: expected false, result is %t)\n",
-				f.Site,
+				"[-] %s: Not working, %s (%s: expected true, result is %t | %s: expected false, result is %t)\n",
+				f.Site, f.Health(),
 				f.UsedUsername, f.Used.Exists,
 				f.UnusedUsername, f.Unused.Exists,
 			)
 		} else {
 			fmt.Fprintf(color.Output,
-				"[-] %s: %s (%s: expected true, result is %t | %s: expected false, result is %t)\n",
+				"[-] %s: %s, %s (%s: expected true, result is %t | %s: expected false, result is %t)\n",
 				f.Site,
-				color.RedString("Not working"),
+				color.RedString("Not working"), f.Health(),
 				f.UsedUsername, f.Used.Exists,
 				f.UnusedUsername, f.Unused.Exists,
 			)
@@ -311,5 +777,93 @@ func runTest(ctx context.Context, stdout io.Writer, noColor bool, scanner *scan.
 			"Please check https://github.com/tdh8316/Investigo/#to-fix-incompatible-sites\n",
 		failCount,
 	)
+
+	if !opts.NoOutput {
+		if err := os.MkdirAll(opts.ResultsDir, 0o755); err != nil {
+			fmt.Fprintf(stderr, "failed to create results dir %q: %v\n", opts.ResultsDir, err)
+			return 1
+		}
+		jsonPath := filepath.Join(opts.ResultsDir, "site-health.json")
+		mdPath := filepath.Join(opts.ResultsDir, "site-health.md")
+		if err := output.WriteHealthFiles(jsonPath, mdPath, report); err != nil {
+			fmt.Fprintf(stderr, "failed to write health report: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Health report written to %s and %s\n", jsonPath, mdPath)
+	}
+
+	if opts.PrunePath != "" {
+		if ctx.Err() != nil {
+			fmt.Fprintln(stderr, "validation interrupted; pruned database not written")
+			return 1
+		}
+		passing := report.Passing()
+		if err := db.Subset(passing).Save(opts.PrunePath); err != nil {
+			fmt.Fprintf(stderr, "failed to write pruned database %q: %v\n", opts.PrunePath, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Pruned database with %d working site(s) written to %s\n", len(passing), opts.PrunePath)
+	}
+
+	if opts.Suggest {
+		return runSuggest(ctx, stdout, stderr, opts, scanner, sites, failing)
+	}
+	return 0
+}
+
+func runSuggest(
+	ctx context.Context,
+	stdout, stderr io.Writer,
+	opts cli.Options,
+	scanner *scan.Scanner,
+	sites map[string]data.SiteData,
+	failing []scan.Validation,
+) int {
+	if opts.NoColor {
+		fmt.Fprintf(stdout, "\n[i] Inferring fixes for %d failing site(s)...\n", len(failing))
+	} else {
+		fmt.Fprintf(color.Output, "\n[%s] Inferring fixes for %d failing site(s)...\n", color.HiBlueString("i"), len(failing))
+	}
+
+	patch := []scan.PatchOp{}
+	_ = scanner.SuggestFixes(ctx, sites, failing, func(sug scan.Suggestion) {
+		if len(sug.Patch) == 0 {
+			fmt.Fprintf(stdout, "[-] %s: no fix (%s)\n", sug.Site, sug.Reason)
+			return
+		}
+		patch = append(patch, sug.Patch...)
+
+		if opts.NoColor {
+			fmt.Fprintf(stdout, "[+] %s: %s\n", sug.Site, sug.Reason)
+		} else {
+			fmt.Fprintf(color.Output, "[%s] %s: %s\n", color.HiGreenString("+"), color.HiWhiteString(sug.Site), sug.Reason)
+		}
+		for _, op := range sug.Patch {
+			if op.Op == "remove" {
+				fmt.Fprintf(stdout, "    remove %s\n", op.Path)
+			} else {
+				fmt.Fprintf(stdout, "    set %s = %v\n", op.Path, op.Value)
+			}
+		}
+	})
+
+	if opts.NoOutput {
+		return 0
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(patch); err != nil {
+		fmt.Fprintf(stderr, "failed to encode suggestions: %v\n", err)
+		return 1
+	}
+	patchPath := filepath.Join(opts.ResultsDir, "site-suggestions.json")
+	if err := os.WriteFile(patchPath, buf.Bytes(), 0o600); err != nil {
+		fmt.Fprintf(stderr, "failed to write %q: %v\n", patchPath, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "JSON patch with %d operation(s) written to %s\n", len(patch), patchPath)
 	return 0
 }
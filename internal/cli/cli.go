@@ -13,26 +13,63 @@ import (
 
 var ErrHelp = errors.New("help message")
 
+// Result output formats.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
 type Options struct {
 	NoColor         bool
 	NoOutput        bool
+	CSV             bool
+	HTML            bool
 	Verbose         bool
 	UpdateBeforeRun bool
 	Test            bool
+	Suggest         bool
+	CheckDatabase   bool
 	WithTor         bool
 	Download        bool
+	NoRedirects     bool
+	NSFW            bool
+	Resume          bool
+
+	// DataFiles are layered in order; the first one is the file --update refreshes.
+	DataFiles []string
+	Sites     []string
+
+	UpdateSource string
+	UpdateCommit string
+	UpdateSHA256 string
+	// UpdateIfOlderThan triggers an update when the database is older than this.
+	UpdateIfOlderThan time.Duration
+	// UpdateChangelog is appended with the changes made by an update.
+	UpdateChangelog string
 
-	DataFile    string
-	Sites       []string
 	Timeout     time.Duration
 	Concurrency int
 	ResultsDir  string
+	Format      string
+	PrunePath   string
+
+	// RateLimit is requests per second per host (0 = unlimited); RateBurst allows short bursts.
+	RateLimit float64
+	RateBurst int
+	// Retries repeats probes that failed transiently, waiting RetryBackoff before the first retry.
+	Retries      int
+	RetryBackoff time.Duration
 }
 
+// Banner is printed at the start of every run and above the usage text.
+const Banner = "Investigo - Investigate Users Across Social Networks."
+
 const usageText = `
 usage:
   investigo [flags] USERNAME [USERNAMES...]
   investigo --test
+  investigo --check-database
 
 positional arguments:
   USERNAMES             one or more usernames to investigate
@@ -41,18 +78,43 @@ flags:
   -h, --help            show this help message and exit
   --no-color            disable colored stdout output
   --no-output           disable file output
+  --csv                 also write results to out.csv
+  --html                also write a self-contained report.html
   --update              update database before run from Sherlock repository
   -t, --tor             use tor proxy
   -v, --verbose         verbose output
   -d, --download        download the contents of site if available
-  --test                validate sites using username_claimed/unclaimed pairs
+  --test                validate sites and write site-health.json/.md reports
+  --suggest             with --test, propose database fixes as a JSON patch
+  --check-database      validate the database files offline and report every problem
+  --no-redirects        inspect redirects of response_url sites instead of following them
+  --nsfw                include NSFW sites in the scan
+  --no-nsfw             exclude NSFW sites unless named in --sites (default)
+  --resume              skip sites already completed by an interrupted run and merge their results
 
 options:
-  --database PATH       use custom database (default: data.json)
+  --database PATH       use custom database file or directory of *.json files (default: data.json);
+                        repeat to layer databases, later entries override earlier ones by site name
+  --update-source SRC   database URL, file:// URL or local path used by --update (default: Sherlock master)
+  --update-commit REF   pin the Sherlock database to a commit or tag
+  --update-sha256 HEX   reject an updated database whose SHA-256 differs
+  --update-if-older-than DURATION
+                        update only if the database was fetched longer ago than DURATION (e.g. 24h)
+  --update-changelog PATH
+                        append a Markdown summary of database changes to PATH
   --sites S1,S2,...     specific sites to investigate separated by comma (default: all sites)
   --timeout SECONDS     HTTP request timeout (default: 60)
   --concurrency N       max concurrent requests (default: 32)
+  --rate N              max requests per second to a single host, 0 to disable (default: 2);
+                        sites may set their own "rateLimit" in the database
+  --rate-burst N        requests a host may receive back to back before --rate applies (default: 2)
+  --retries N           retry timeouts, refused or reset connections, HTTP 429 and 5xx
+                        up to N times (default: 2)
+  --retry-backoff DURATION
+                        delay before the first retry, doubled for each further one (default: 500ms)
   --results DIR         output directory (default: results)
+  --prune PATH          with --test, write a database of only the passing sites to PATH
+  --format FORMAT       result format: text, json or ndjson (default: text)
 `
 
 func Parse(args []string, stdout, stderr io.Writer) (Options, []string, error) {
@@ -61,13 +123,14 @@ func Parse(args []string, stdout, stderr io.Writer) (Options, []string, error) {
 		help     bool
 		sitesCSV string
 		timeoutS int
+		noNSFW   bool
 	)
 
 	fs := flag.NewFlagSet("investigo", flag.ContinueOnError)
 	fs.SetOutput(stderr)
 
 	fs.Usage = func() {
-		_, _ = fmt.Fprint(stdout, usageText)
+		_, _ = fmt.Fprint(stdout, Banner+"\n"+usageText)
 	}
 
 	// Help
@@ -77,22 +140,41 @@ func Parse(args []string, stdout, stderr io.Writer) (Options, []string, error) {
 	// Behavior flags
 	fs.BoolVar(&opts.NoColor, "no-color", false, "disable colored output")
 	fs.BoolVar(&opts.NoOutput, "no-output", false, "disable file output")
+	fs.BoolVar(&opts.CSV, "csv", false, "write results to out.csv")
+	fs.BoolVar(&opts.HTML, "html", false, "write an HTML report")
 	fs.BoolVar(&opts.UpdateBeforeRun, "update", false, "update database before run")
 	fs.BoolVar(&opts.Test, "test", false, "validate site database")
+	fs.BoolVar(&opts.Suggest, "suggest", false, "suggest fixes for failing sites (with --test)")
+	fs.BoolVar(&opts.CheckDatabase, "check-database", false, "validate database files")
 	fs.BoolVar(&opts.Verbose, "v", false, "verbose output")
 	fs.BoolVar(&opts.Verbose, "verbose", false, "verbose output")
 	fs.BoolVar(&opts.WithTor, "t", false, "use tor proxy")
 	fs.BoolVar(&opts.WithTor, "tor", false, "use tor proxy")
 	fs.BoolVar(&opts.Download, "d", false, "download contents if downloader exists")
 	fs.BoolVar(&opts.Download, "download", false, "download contents if downloader exists")
+	fs.BoolVar(&opts.NoRedirects, "no-redirects", false, "don't follow redirects for response_url sites")
+	fs.BoolVar(&opts.NSFW, "nsfw", false, "include NSFW sites")
+	fs.BoolVar(&noNSFW, "no-nsfw", false, "exclude NSFW sites (default)")
+	fs.BoolVar(&opts.Resume, "resume", false, "resume an interrupted scan from its checkpoint")
 
 	// Options
-	fs.StringVar(&opts.DataFile, "database", "data.json", "custom database path")
+	fs.Var((*stringList)(&opts.DataFiles), "database", "custom database path (repeatable)")
+	fs.StringVar(&opts.UpdateSource, "update-source", "", "database update source URL or path")
+	fs.StringVar(&opts.UpdateCommit, "update-commit", "", "pin the Sherlock database to a commit")
+	fs.StringVar(&opts.UpdateSHA256, "update-sha256", "", "expected SHA-256 of the updated database")
+	fs.DurationVar(&opts.UpdateIfOlderThan, "update-if-older-than", 0, "update when the database is older than this")
+	fs.StringVar(&opts.UpdateChangelog, "update-changelog", "", "append database changes to this file")
 	fs.StringVar(&sitesCSV, "sites", "", "comma-separated site list")
 	fs.StringVar(&sitesCSV, "site", "", "comma-separated site list (compat)") // compat with old flag
 	fs.IntVar(&timeoutS, "timeout", 60, "request timeout in seconds")
 	fs.IntVar(&opts.Concurrency, "concurrency", 32, "max concurrent requests")
+	fs.Float64Var(&opts.RateLimit, "rate", 2, "max requests per second per host")
+	fs.IntVar(&opts.RateBurst, "rate-burst", 2, "max back-to-back requests per host")
+	fs.IntVar(&opts.Retries, "retries", 2, "retries for transient failures")
+	fs.DurationVar(&opts.RetryBackoff, "retry-backoff", 500*time.Millisecond, "delay before the first retry")
 	fs.StringVar(&opts.ResultsDir, "results", "results", "results output directory")
+	fs.StringVar(&opts.Format, "format", FormatText, "result format (text, json, ndjson)")
+	fs.StringVar(&opts.PrunePath, "prune", "", "write passing sites to this database path (with --test)")
 
 	if err := fs.Parse(args); err != nil {
 		return Options{}, nil, err
@@ -102,10 +184,49 @@ func Parse(args []string, stdout, stderr io.Writer) (Options, []string, error) {
 		return Options{}, nil, ErrHelp
 	}
 
+	if opts.Suggest && !opts.Test {
+		return Options{}, nil, errors.New("--suggest requires --test")
+	}
+	if opts.PrunePath != "" && !opts.Test {
+		return Options{}, nil, errors.New("--prune requires --test")
+	}
+
+	if opts.Resume && (opts.NoOutput || opts.Test) {
+		return Options{}, nil, errors.New("--resume can't be combined with --no-output or --test")
+	}
+
+	if opts.RateLimit < 0 {
+		return Options{}, nil, fmt.Errorf("invalid --rate %g (want 0 or more)", opts.RateLimit)
+	}
+	if opts.RateBurst < 1 {
+		return Options{}, nil, fmt.Errorf("invalid --rate-burst %d (want 1 or more)", opts.RateBurst)
+	}
+
+	if opts.Retries < 0 {
+		return Options{}, nil, fmt.Errorf("invalid --retries %d (want 0 or more)", opts.Retries)
+	}
+	if opts.RetryBackoff <= 0 {
+		return Options{}, nil, fmt.Errorf("invalid --retry-backoff %s (want a positive duration)", opts.RetryBackoff)
+	}
+
+	if opts.UpdateSource != "" && opts.UpdateCommit != "" {
+		return Options{}, nil, errors.New("--update-source and --update-commit are mutually exclusive")
+	}
+
+	opts.Format = strings.ToLower(strings.TrimSpace(opts.Format))
+	switch opts.Format {
+	case FormatText, FormatJSON, FormatNDJSON:
+	default:
+		return Options{}, nil, fmt.Errorf("invalid --format %q (want text, json or ndjson)", opts.Format)
+	}
+
 	if timeoutS <= 0 {
 		// Don't allow zero or negative timeouts; reset to default.
 		timeoutS = 60
-		if opts.NoColor {
+		if opts.Format != FormatText {
+			// stdout carries only records in machine-readable formats.
+			fmt.Fprintf(stderr, "[!] Invalid timeout value; using default of 60 seconds.\n")
+		} else if opts.NoColor {
 			fmt.Fprintf(stdout, "[!] Invalid timeout value; using default of 60 seconds.\n")
 		} else {
 			fmt.Fprintf(color.Output, "[%s] Invalid timeout value; using default of %s.\n",
@@ -116,6 +237,14 @@ func Parse(args []string, stdout, stderr io.Writer) (Options, []string, error) {
 	}
 	opts.Timeout = time.Duration(timeoutS) * time.Second
 
+	if noNSFW {
+		opts.NSFW = false
+	}
+
+	if len(opts.DataFiles) == 0 {
+		opts.DataFiles = []string{"data.json"}
+	}
+
 	if opts.Concurrency <= 0 {
 		opts.Concurrency = 32
 	}
@@ -136,3 +265,15 @@ func Parse(args []string, stdout, stderr io.Writer) (Options, []string, error) {
 	usernames := fs.Args()
 	return opts, usernames, nil
 }
+
+// stringList is a flag.Value collecting every occurrence of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInvalidTimeout(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantStdout bool
+	}{
+		{[]string{"--no-color", "--timeout", "0", "alice"}, true},
+		{[]string{"--format", "ndjson", "--timeout", "0", "alice"}, false},
+		{[]string{"--format", "json", "--no-color", "--timeout", "-1", "alice"}, false},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		opts, _, err := Parse(tt.args, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.args, err)
+		}
+		if opts.Timeout != 60*time.Second {
+			t.Errorf("Parse(%q): timeout = %s, want 60s", tt.args, opts.Timeout)
+		}
+
+		warned, quiet := &stdout, &stderr
+		if !tt.wantStdout {
+			warned, quiet = &stderr, &stdout
+		}
+		if !strings.Contains(warned.String(), "Invalid timeout") || quiet.Len() != 0 {
+			t.Errorf("Parse(%q): stdout=%q stderr=%q", tt.args, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestParseValidation(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--suggest"}, "--suggest requires --test"},
+		{[]string{"--prune", "out.json"}, "--prune requires --test"},
+		{[]string{"--resume", "--no-output", "alice"}, "--resume"},
+		{[]string{"--update-source", "x", "--update-commit", "y"}, "mutually exclusive"},
+		{[]string{"--format", "xml"}, "invalid --format"},
+		{[]string{"--rate", "-1"}, "invalid --rate"},
+		{[]string{"--retries", "-1"}, "invalid --retries"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		_, _, err := Parse(tt.args, &out, &out)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestParseHelp(t *testing.T) {
+	for _, args := range [][]string{{"-h"}, {"--help"}, {"--bogus"}} {
+		var stdout, stderr bytes.Buffer
+		_, _, err := Parse(args, &stdout, &stderr)
+		if err == nil {
+			t.Fatalf("Parse(%q) succeeded", args)
+		}
+		if !strings.HasPrefix(stdout.String(), Banner+"\n\nusage:") {
+			t.Errorf("Parse(%q): stdout = %q, want the banner and usage", args, stdout.String())
+		}
+	}
+}
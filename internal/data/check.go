@@ -0,0 +1,244 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dlclark/regexp2"
+)
+
+// Problem is a single issue found in a database entry.
+type Problem struct {
+	Site    string
+	Field   string // empty for entry-level problems
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Field == "" {
+		return fmt.Sprintf("%s: %s", p.Site, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Site, p.Field, p.Message)
+}
+
+var (
+	errorTypes     = []string{"message", "response_url", "status_code"}
+	requestMethods = []string{"GET", "HEAD", "POST", "PUT"}
+	requiredFields = []string{"errorType", "url", "urlMain", "username_claimed"}
+	stringFields   = []string{
+		"errorType", "url", "urlMain", "urlProbe", "errorUrl",
+		"username_claimed", "username_unclaimed", "regexCheck", "request_method", "category",
+	}
+)
+
+// CheckFile validates every entry of a database file and returns all problems found.
+// The error is non-nil only when the file can't be read or isn't a JSON object.
+func CheckFile(filename string) ([]Problem, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Check(raw)
+}
+
+// Check validates database JSON against the Sherlock schema and a few
+// semantic rules (usable URL templates, errorMsg for message sites,
+// compilable regexCheck). Problems are sorted by site name.
+func Check(raw []byte) ([]Problem, error) {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []Problem
+	for _, name := range names {
+		if name == "$schema" {
+			var schema string
+			if err := json.Unmarshal(entries[name], &schema); err != nil {
+				problems = append(problems, Problem{Site: name, Message: "must be a string"})
+			}
+			continue
+		}
+		problems = append(problems, checkSite(name, entries[name])...)
+	}
+	return problems, nil
+}
+
+func checkSite(name string, msg json.RawMessage) []Problem {
+	var problems []Problem
+	add := func(field, format string, args ...any) {
+		problems = append(problems, Problem{Site: name, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// null removes the site when layering databases.
+	if string(msg) == "null" {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &fields); err != nil {
+		add("", "entry must be a JSON object")
+		return problems
+	}
+	if disabled, ok := fields["disabled"]; ok && string(disabled) == "true" {
+		return nil
+	}
+
+	// Schema: required fields and types.
+	for _, f := range requiredFields {
+		if _, ok := fields[f]; !ok {
+			add(f, "required field is missing")
+		}
+	}
+
+	str := make(map[string]string)
+	for _, f := range stringFields {
+		v, ok := fields[f]
+		if !ok {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			add(f, "must be a string")
+			continue
+		}
+		str[f] = s
+	}
+
+	if v, ok := fields["errorMsg"]; ok && !isStringOrStrings(v) {
+		add("errorMsg", "must be a string or a list of strings")
+	}
+	if v, ok := fields["errorCode"]; ok {
+		var codes StatusCodes
+		if err := json.Unmarshal(v, &codes); err != nil {
+			add("errorCode", "must be an integer or a list of integers")
+		}
+	}
+	if v, ok := fields["headers"]; ok {
+		var headers map[string]string
+		if err := json.Unmarshal(v, &headers); err != nil {
+			add("headers", "must be an object of string values")
+		}
+	}
+	if v, ok := fields["isNSFW"]; ok {
+		var b bool
+		if err := json.Unmarshal(v, &b); err != nil {
+			add("isNSFW", "must be a boolean")
+		}
+	}
+	if v, ok := fields["rateLimit"]; ok {
+		var rate float64
+		if err := json.Unmarshal(v, &rate); err != nil || rate <= 0 {
+			add("rateLimit", "must be a positive number")
+		}
+	}
+	var payload map[string]any
+	if v, ok := fields["request_payload"]; ok {
+		if err := json.Unmarshal(v, &payload); err != nil {
+			add("request_payload", "must be an object")
+		}
+	}
+
+	if t, ok := str["errorType"]; ok && !slices.Contains(errorTypes, t) {
+		add("errorType", "%q is not one of %s", t, strings.Join(errorTypes, ", "))
+	}
+	if m, ok := str["request_method"]; ok && !slices.Contains(requestMethods, m) {
+		add("request_method", "%q is not one of %s", m, strings.Join(requestMethods, ", "))
+	}
+
+	// Semantics.
+	for _, f := range []string{"url", "urlMain", "urlProbe", "errorUrl"} {
+		u, ok := str[f]
+		if !ok || (f == "errorUrl" && !strings.Contains(u, "://")) {
+			// errorUrl may be a scheme-less fragment matched as a substring.
+			continue
+		}
+		if parsed, err := url.Parse(strings.ReplaceAll(u, "{}", "x")); err != nil ||
+			(parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add(f, "%q is not an absolute http(s) URL", u)
+		}
+	}
+
+	// The username may be carried by the probe URL or the payload instead.
+	if u, ok := str["url"]; ok && !strings.Contains(u, "{}") &&
+		!strings.Contains(str["urlProbe"], "{}") && !hasPlaceholder(payload) {
+		add("url", "does not contain the {} username placeholder")
+	}
+
+	if str["errorType"] == "message" {
+		var errorMsg any
+		_ = json.Unmarshal(fields["errorMsg"], &errorMsg)
+		if !hasErrorMsg(errorMsg) {
+			add("errorMsg", "required for errorType \"message\"")
+		}
+	}
+
+	if expr, ok := str["regexCheck"]; ok {
+		re, err := regexp2.Compile(expr, 0)
+		if err != nil {
+			add("regexCheck", "does not compile: %v", err)
+		} else if claimed, ok := str["username_claimed"]; ok {
+			if match, err := re.MatchString(claimed); err == nil && !match {
+				add("regexCheck", "username_claimed %q does not match %q", claimed, expr)
+			}
+		}
+	}
+
+	return problems
+}
+
+func isStringOrStrings(v json.RawMessage) bool {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return true
+	}
+	var list []string
+	return json.Unmarshal(v, &list) == nil
+}
+
+// hasPlaceholder reports whether a string value anywhere in a decoded
+// request_payload contains {}. Keys and empty objects don't count.
+func hasPlaceholder(v any) bool {
+	switch t := v.(type) {
+	case string:
+		return strings.Contains(t, "{}")
+	case map[string]any:
+		for _, it := range t {
+			if hasPlaceholder(it) {
+				return true
+			}
+		}
+	case []any:
+		for _, it := range t {
+			if hasPlaceholder(it) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasErrorMsg(v any) bool {
+	switch t := v.(type) {
+	case string:
+		return t != ""
+	case []any:
+		for _, it := range t {
+			if s, ok := it.(string); ok && s != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
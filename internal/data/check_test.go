@@ -0,0 +1,85 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+		want  []string // substrings of the expected problems, in order
+	}{
+		{
+			name:  "valid",
+			entry: `{"errorType":"status_code","url":"https://example.com/{}","urlMain":"https://example.com","username_claimed":"alice"}`,
+		},
+		{
+			name:  "placeholder in urlProbe",
+			entry: `{"errorType":"status_code","url":"https://example.com/","urlProbe":"https://api.example.com/{}","urlMain":"https://example.com","username_claimed":"alice"}`,
+		},
+		{
+			name:  "placeholder in payload",
+			entry: `{"errorType":"status_code","url":"https://example.com/","request_method":"POST","request_payload":{"user":"{}"},"urlMain":"https://example.com","username_claimed":"alice"}`,
+		},
+		{
+			name:  "placeholder nested in payload",
+			entry: `{"errorType":"status_code","url":"https://example.com/","request_method":"POST","request_payload":{"query":{"users":["{}"]}},"urlMain":"https://example.com","username_claimed":"alice"}`,
+		},
+		{
+			name:  "empty object in payload",
+			entry: `{"errorType":"status_code","url":"https://example.com/","request_method":"POST","request_payload":{"filter":{}},"urlMain":"https://example.com","username_claimed":"alice"}`,
+			want:  []string{"url: does not contain the {} username placeholder"},
+		},
+		{
+			name:  "no placeholder",
+			entry: `{"errorType":"status_code","url":"https://example.com/","urlMain":"https://example.com","username_claimed":"alice"}`,
+			want:  []string{"url: does not contain the {} username placeholder"},
+		},
+		{
+			name:  "missing fields",
+			entry: `{}`,
+			want:  []string{"errorType: required", "url: required", "urlMain: required", "username_claimed: required"},
+		},
+		{
+			name:  "message without errorMsg",
+			entry: `{"errorType":"message","url":"https://example.com/{}","urlMain":"https://example.com","username_claimed":"alice"}`,
+			want:  []string{"errorMsg: required for errorType \"message\""},
+		},
+		{
+			name:  "claimed username rejected by regexCheck",
+			entry: `{"errorType":"status_code","url":"https://example.com/{}","urlMain":"https://example.com","username_claimed":"al","regexCheck":"^[a-z]{3,}$"}`,
+			want:  []string{"regexCheck: username_claimed \"al\" does not match"},
+		},
+		{
+			name:  "relative url",
+			entry: `{"errorType":"status_code","url":"/{}","urlMain":"https://example.com","username_claimed":"alice"}`,
+			want:  []string{"url: \"/{}\" is not an absolute http(s) URL"},
+		},
+		{
+			name:  "category",
+			entry: `{"errorType":"status_code","url":"https://example.com/{}","urlMain":"https://example.com","username_claimed":"alice","category":["social"]}`,
+			want:  []string{"category: must be a string"},
+		},
+		{name: "null", entry: `null`},
+		{name: "disabled", entry: `{"disabled":true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := Check([]byte(`{"Example":` + tt.entry + `}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(problems) != len(tt.want) {
+				t.Fatalf("got %d problems %v, want %d", len(problems), problems, len(tt.want))
+			}
+			for i, p := range problems {
+				if !strings.Contains(p.String(), tt.want[i]) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, p.String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -1,96 +1,109 @@
 package data
 
 import (
-	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
-const SherlockDataURL = "https://raw.githubusercontent.com/sherlock-project/sherlock/refs/heads/master/sherlock_project/resources/data.json"
-
 type SiteData struct {
-	ErrorType string `json:"errorType"`
-	ErrorMsg  any    `json:"errorMsg"`
-
-	URL      string `json:"url"`
-	URLMain  string `json:"urlMain"`
-	URLProbe string `json:"urlProbe"`
-	URLError string `json:"errorUrl"`
-
-	UsedUsername   string `json:"username_claimed"`
-	UnusedUsername string `json:"username_unclaimed"`
-	RegexCheck     string `json:"regexCheck"`
+	ErrorType string `json:"errorType,omitempty"`
+	ErrorMsg  any    `json:"errorMsg,omitempty"`
+	// ErrorCode lists the HTTP status codes that mean "not found" for errorType=status_code.
+	ErrorCode StatusCodes `json:"errorCode,omitempty"`
+
+	URL      string `json:"url,omitempty"`
+	URLMain  string `json:"urlMain,omitempty"`
+	URLProbe string `json:"urlProbe,omitempty"`
+	URLError string `json:"errorUrl,omitempty"`
+
+	UsedUsername   string `json:"username_claimed,omitempty"`
+	UnusedUsername string `json:"username_unclaimed,omitempty"`
+	RegexCheck     string `json:"regexCheck,omitempty"`
+	IsNSFW         bool   `json:"isNSFW,omitempty"`
+
+	// Category groups sites in reports (e.g. "social", "coding"); free-form.
+	Category string `json:"category,omitempty"`
+
+	// RequestMethod is the HTTP method used for the probe (default: GET).
+	RequestMethod string `json:"request_method,omitempty"`
+	// RequestPayload is sent as a JSON body; "{}" in string values is replaced by the username.
+	RequestPayload any `json:"request_payload,omitempty"`
+	// Headers are added to the probe request; "{}" in values is replaced by the username.
+	Headers map[string]string `json:"headers,omitempty"`
+	// RateLimit caps requests per second to the site's host, overriding the scanner default.
+	RateLimit float64 `json:"rateLimit,omitempty"`
+
+	// Disabled removes the site when layering databases (see LoadMerged).
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Raw is the entry as read from the database, including fields not modeled above.
+	Raw json.RawMessage `json:"-"`
 }
 
-func LoadSites(filename string) (map[string]SiteData, error) {
-	raw, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
+// StatusCodes is a list of HTTP status codes that decodes from either
+// a single integer or an array of integers.
+type StatusCodes []int
 
-	var entries map[string]json.RawMessage
-	if err := json.Unmarshal(raw, &entries); err != nil {
-		return nil, fmt.Errorf("parse json: %w", err)
+func (c *StatusCodes) UnmarshalJSON(b []byte) error {
+	var one int
+	if err := json.Unmarshal(b, &one); err == nil {
+		*c = StatusCodes{one}
+		return nil
 	}
 
-	out := make(map[string]SiteData, len(entries))
-	for siteName, msg := range entries {
-		// Skip the JSON Schema entry if present.
-		if siteName == "$schema" {
-			continue
-		}
-
-		var sd SiteData
-		if err := json.Unmarshal(msg, &sd); err != nil {
-			return nil, fmt.Errorf("site %q: %w", siteName, err)
-		}
-		out[siteName] = sd
+	var many []int
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("errorCode must be an integer or a list of integers: %s", string(b))
 	}
+	*c = many
+	return nil
+}
 
-	return out, nil
+// MarshalJSON writes a single code as a plain integer, like the database does.
+func (c StatusCodes) MarshalJSON() ([]byte, error) {
+	if len(c) == 1 {
+		return json.Marshal(c[0])
+	}
+	return json.Marshal([]int(c))
 }
 
-type Doer interface {
-	Do(req *http.Request) (*http.Response, error)
+// Contains reports whether code is in the list.
+func (c StatusCodes) Contains(code int) bool {
+	return slices.Contains(c, code)
 }
 
-func UpdateFromRemote(ctx context.Context, client Doer, userAgent string, destPath string) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, SherlockDataURL, nil)
-	if err != nil {
+func writeFileAtomic(destPath string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
 		return err
 	}
-	if userAgent != "" {
-		req.Header.Set("User-Agent", userAgent)
-	}
 
-	resp, err := client.Do(req)
-	if err != nil {
+	tmp := destPath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		// Read a small snippet for diagnostics.
-		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return fmt.Errorf("download failed: %s (%s)", resp.Status, string(snippet))
-	}
+	return os.Rename(tmp, destPath)
+}
 
-	body, err := io.ReadAll(resp.Body)
+// Checksum returns the hex-encoded SHA-256 of the database files, in order.
+// Directories are expanded as in LoadMerged.
+func Checksum(paths ...string) (string, error) {
+	files, err := ExpandPaths(paths...)
 	if err != nil {
-		return err
-	}
-
-	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
-		return err
+		return "", err
 	}
 
-	tmp := destPath + ".tmp"
-	if err := os.WriteFile(tmp, body, 0o600); err != nil {
-		return err
+	h := sha256.New()
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		h.Write(raw)
 	}
-	return os.Rename(tmp, destPath)
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
@@ -0,0 +1,37 @@
+package data
+
+import "testing"
+
+func TestStatusCodes(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    []int
+		wantErr bool
+	}{
+		{`404`, []int{404}, false},
+		{`[404, 410]`, []int{404, 410}, false},
+		{`[]`, []int{}, false},
+		{`"404"`, nil, true},
+	}
+	for _, tt := range tests {
+		var c StatusCodes
+		err := c.UnmarshalJSON([]byte(tt.json))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("UnmarshalJSON(%s) error = %v", tt.json, err)
+			continue
+		}
+		if !tt.wantErr && (len(c) != len(tt.want) || (len(c) > 0 && c[0] != tt.want[0])) {
+			t.Errorf("UnmarshalJSON(%s) = %v, want %v", tt.json, c, tt.want)
+		}
+	}
+
+	if b, _ := (StatusCodes{404}).MarshalJSON(); string(b) != `404` {
+		t.Errorf("MarshalJSON single = %s", b)
+	}
+	if b, _ := (StatusCodes{404, 410}).MarshalJSON(); string(b) != `[404,410]` {
+		t.Errorf("MarshalJSON list = %s", b)
+	}
+	if !(StatusCodes{404, 410}).Contains(410) || (StatusCodes{404}).Contains(200) {
+		t.Error("Contains is wrong")
+	}
+}
@@ -0,0 +1,334 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Database is a parsed site database. Every SiteData keeps its raw JSON so
+// the database can be written back without losing fields it doesn't model.
+type Database struct {
+	// Schema is the raw "$schema" value, if present.
+	Schema json.RawMessage
+	Sites  map[string]SiteData
+
+	// order is the key order of the source file(s), so Marshal can write
+	// entries back where they were.
+	order []string
+}
+
+// Load reads and parses a database file.
+func Load(filename string) (*Database, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// Parse parses database JSON.
+func Parse(raw []byte) (*Database, error) {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+	order, err := objectKeys(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+
+	db := &Database{Sites: make(map[string]SiteData, len(entries)), order: order}
+	for siteName, msg := range entries {
+		// Keep the JSON Schema entry aside if present.
+		if siteName == "$schema" {
+			db.Schema = msg
+			continue
+		}
+
+		var sd SiteData
+		if err := json.Unmarshal(msg, &sd); err != nil {
+			return nil, fmt.Errorf("site %q: %w", siteName, err)
+		}
+		sd.Raw = msg
+		db.Sites[siteName] = sd
+	}
+
+	return db, nil
+}
+
+func LoadSites(filename string) (map[string]SiteData, error) {
+	db, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return db.Sites, nil
+}
+
+// LoadMerged loads databases in order and layers them by site name: a later
+// entry replaces an earlier one entirely, and an entry that is null or has
+// "disabled": true removes the site. Directories contribute their *.json
+// files in lexical order.
+func LoadMerged(paths ...string) (*Database, error) {
+	files, err := ExpandPaths(paths...)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no database files found in %s", strings.Join(paths, ", "))
+	}
+
+	merged := &Database{Sites: map[string]SiteData{}}
+	seen := map[string]bool{}
+	for _, file := range files {
+		db, err := Load(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if merged.Schema == nil {
+			merged.Schema = db.Schema
+		}
+		for _, key := range db.order {
+			if !seen[key] {
+				seen[key] = true
+				merged.order = append(merged.order, key)
+			}
+		}
+		for name, sd := range db.Sites {
+			if sd.Disabled || string(sd.Raw) == "null" {
+				delete(merged.Sites, name)
+				continue
+			}
+			merged.Sites[name] = sd
+		}
+	}
+	return merged, nil
+}
+
+// ExpandPaths replaces directories with the *.json files they contain, sorted by name.
+func ExpandPaths(paths ...string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(p, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// Subset returns a database containing only the named sites.
+func (db *Database) Subset(names []string) *Database {
+	out := &Database{Schema: db.Schema, Sites: make(map[string]SiteData, len(names)), order: db.order}
+	for _, name := range names {
+		if sd, ok := db.Sites[name]; ok {
+			out.Sites[name] = sd
+		}
+	}
+	return out
+}
+
+// Save writes the database to filename (atomically).
+func (db *Database) Save(filename string) error {
+	b, err := db.Marshal()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, b)
+}
+
+// Marshal encodes the database as indented JSON, keeping the key order of
+// the source file; sites that weren't in it are appended by name. Unedited
+// entries are written byte for byte from their raw JSON; edited ones have
+// their modeled fields merged into it.
+func (db *Database) Marshal() ([]byte, error) {
+	var keys []string
+	listed := map[string]bool{}
+	for _, key := range db.order {
+		_, isSite := db.Sites[key]
+		if (isSite || (key == "$schema" && db.Schema != nil)) && !listed[key] {
+			listed[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if db.Schema != nil && !listed["$schema"] {
+		keys = append([]string{"$schema"}, keys...)
+	}
+	var added []string
+	for name := range db.Sites {
+		if !listed[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	keys = append(keys, added...)
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, key := range keys {
+		var (
+			value    json.RawMessage
+			verbatim bool
+		)
+		if key == "$schema" {
+			value = db.Schema
+		} else {
+			sd := db.Sites[key]
+			var err error
+			if value, verbatim, err = sd.encode(); err != nil {
+				return nil, fmt.Errorf("site %q: %w", key, err)
+			}
+		}
+
+		name, err := marshalNoEscape(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("  ")
+		buf.Write(name)
+		buf.WriteString(": ")
+		if verbatim {
+			buf.Write(value)
+		} else if err := json.Indent(&buf, value, "  ", "  "); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if i < len(keys)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// encode returns the entry's JSON, preserving unmodeled fields and the field
+// order from Raw. verbatim is set when Raw is returned unchanged.
+func (sd SiteData) encode() (msg json.RawMessage, verbatim bool, err error) {
+	modeled, err := marshalNoEscape(sd)
+	if err != nil {
+		return nil, false, err
+	}
+	if sd.Raw == nil {
+		return modeled, false, nil
+	}
+
+	var orig SiteData
+	if err := json.Unmarshal(sd.Raw, &orig); err == nil {
+		orig.Raw = sd.Raw
+		if reflect.DeepEqual(orig, sd) {
+			return sd.Raw, true, nil
+		}
+	}
+
+	var fields, updates map[string]json.RawMessage
+	if err := json.Unmarshal(sd.Raw, &fields); err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(modeled, &updates); err != nil {
+		return nil, false, err
+	}
+	keys, err := objectKeys(sd.Raw)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, key := range modeledFields {
+		v, ok := updates[key]
+		if !ok {
+			delete(fields, key)
+			continue
+		}
+		if _, had := fields[key]; !had {
+			keys = append(keys, key)
+		}
+		fields[key] = v
+	}
+
+	// Write the fields in their original order, with new ones at the end.
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	n := 0
+	for _, key := range keys {
+		v, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		n++
+		name, err := marshalNoEscape(key)
+		if err != nil {
+			return nil, false, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), false, nil
+}
+
+// objectKeys returns the keys of a JSON object in document order.
+func objectKeys(raw []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// marshalNoEscape is json.Marshal without HTML escaping, since entries
+// routinely contain markup (e.g. errorMsg "<title>...</title>").
+func marshalNoEscape(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// modeledFields are the JSON keys SiteData decodes.
+var modeledFields = func() []string {
+	var keys []string
+	t := reflect.TypeFor[SiteData]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}()
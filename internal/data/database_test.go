@@ -0,0 +1,209 @@
+package data
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// unsortedDatabase has keys and fields out of byte order, an unmodeled field
+// and markup that json.Marshal would escape.
+const unsortedDatabase = `{
+  "$schema": "data.schema.json",
+  "zeta": {
+    "url": "https://zeta.example/{}",
+    "errorType": "message",
+    "errorMsg": "<title>Not Found</title>",
+    "urlMain": "https://zeta.example/",
+    "username_claimed": "alice",
+    "tags": ["x", "y"]
+  },
+  "Alpha": {
+    "errorType": "status_code",
+    "url": "https://alpha.example/{}",
+    "urlMain": "https://alpha.example/",
+    "username_claimed": "alice"
+  },
+  "beta": {"errorType": "status_code", "url": "https://beta.example/{}", "urlMain": "https://beta.example/", "username_claimed": "bob"}
+}
+`
+
+func TestMarshalRoundTrip(t *testing.T) {
+	db, err := Parse([]byte(unsortedDatabase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := db.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != unsortedDatabase {
+		t.Errorf("round trip changed the database:\n%s", out)
+	}
+}
+
+func TestSaveRoundTripShippedDatabase(t *testing.T) {
+	raw, err := os.ReadFile("../../data.json")
+	if err != nil {
+		t.Skip(err)
+	}
+	db, err := Load("../../data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only blank lines between entries may differ.
+	dropBlank := func(b []byte) string {
+		return strings.ReplaceAll(string(b), "\n\n", "\n")
+	}
+	if dropBlank(raw) != dropBlank(out) {
+		t.Error("Load→Save changed data.json beyond blank lines")
+	}
+}
+
+func TestMarshalEditedEntry(t *testing.T) {
+	db, err := Parse([]byte(unsortedDatabase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zeta := db.Sites["zeta"]
+	zeta.ErrorMsg = "<b>Gone</b>"
+	zeta.ErrorCode = StatusCodes{404}
+	zeta.URLMain = ""
+	db.Sites["zeta"] = zeta
+	db.Sites["Gamma"] = SiteData{ErrorType: "status_code", URL: "https://gamma.example/{}"}
+
+	out, err := db.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "$schema": "data.schema.json",
+  "zeta": {
+    "url": "https://zeta.example/{}",
+    "errorType": "message",
+    "errorMsg": "<b>Gone</b>",
+    "username_claimed": "alice",
+    "tags": [
+      "x",
+      "y"
+    ],
+    "errorCode": 404
+  },
+  "Alpha": {
+    "errorType": "status_code",
+    "url": "https://alpha.example/{}",
+    "urlMain": "https://alpha.example/",
+    "username_claimed": "alice"
+  },
+  "beta": {"errorType": "status_code", "url": "https://beta.example/{}", "urlMain": "https://beta.example/", "username_claimed": "bob"},
+  "Gamma": {
+    "errorType": "status_code",
+    "url": "https://gamma.example/{}"
+  }
+}
+`
+	if string(out) != want {
+		t.Errorf("Marshal =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestSubsetKeepsOrder(t *testing.T) {
+	db, err := Parse([]byte(unsortedDatabase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := db.Subset([]string{"beta", "zeta"}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z, b := bytes.Index(out, []byte(`"zeta"`)), bytes.Index(out, []byte(`"beta"`)); z < 0 || b < 0 || z > b {
+		t.Errorf("Subset reordered sites:\n%s", out)
+	}
+	if bytes.Contains(out, []byte(`"Alpha"`)) {
+		t.Errorf("Subset kept an unselected site:\n%s", out)
+	}
+}
+
+func TestLoadMerged(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	base := write("data.json", unsortedDatabase)
+	write("overrides/10-beta.json", `{"beta": {"errorType": "status_code", "url": "https://beta.example/u/{}"}}`)
+	write("overrides/20-drop.json", `{"zeta": null, "Alpha": {"disabled": true}, "Delta": {"errorType": "status_code", "url": "https://delta.example/{}"}}`)
+	write("overrides/notes.txt", `not a database`)
+
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []string
+		betaURL string
+	}{
+		{"base only", []string{base}, []string{"Alpha", "beta", "zeta"}, "https://beta.example/{}"},
+		{"directory in name order", []string{base, filepath.Join(dir, "overrides")}, []string{"Delta", "beta"}, "https://beta.example/u/{}"},
+		{"single override", []string{base, filepath.Join(dir, "overrides/20-drop.json")}, []string{"Delta", "beta"}, "https://beta.example/{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := LoadMerged(tt.paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := siteNames(db); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sites = %v, want %v", got, tt.want)
+			}
+			if got := db.Sites["beta"].URL; got != tt.betaURL {
+				t.Errorf("beta url = %q, want %q", got, tt.betaURL)
+			}
+		})
+	}
+
+	// A site removed by an override can be brought back by a later file.
+	revive := write("revive.json", `{"zeta": {"errorType": "status_code", "url": "https://zeta.example/v2/{}"}}`)
+	db, err := LoadMerged(base, filepath.Join(dir, "overrides"), revive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Sites["zeta"].URL; got != "https://zeta.example/v2/{}" {
+		t.Errorf("revived zeta url = %q", got)
+	}
+
+	if _, err := LoadMerged(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadMerged of a missing path succeeded")
+	}
+	if _, err := LoadMerged(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no database files") {
+		t.Errorf("LoadMerged of an empty directory: err = %v", err)
+	}
+}
+
+func siteNames(db *Database) []string {
+	names := make([]string, 0, len(db.Sites))
+	for name := range db.Sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,132 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Changes summarizes the differences between two databases.
+type Changes struct {
+	Added    []string
+	Removed  []string
+	Modified []SiteChange
+}
+
+// SiteChange lists the fields of a site that were added, removed or changed.
+type SiteChange struct {
+	Site   string
+	Fields []string
+}
+
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Diff compares two databases entry by entry, field by field.
+func Diff(old, new *Database) Changes {
+	var c Changes
+	for name := range new.Sites {
+		if _, ok := old.Sites[name]; !ok {
+			c.Added = append(c.Added, name)
+		}
+	}
+	for name, before := range old.Sites {
+		after, ok := new.Sites[name]
+		if !ok {
+			c.Removed = append(c.Removed, name)
+			continue
+		}
+		if fields := changedFields(before, after); len(fields) > 0 {
+			c.Modified = append(c.Modified, SiteChange{Site: name, Fields: fields})
+		}
+	}
+
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Slice(c.Modified, func(i, j int) bool { return c.Modified[i].Site < c.Modified[j].Site })
+	return c
+}
+
+func changedFields(before, after SiteData) []string {
+	a, errA := siteFields(before)
+	b, errB := siteFields(after)
+	if errA != nil || errB != nil {
+		return []string{"(entry)"}
+	}
+
+	var fields []string
+	for k, va := range a {
+		if vb, ok := b[k]; !ok || !jsonEqual(va, vb) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func siteFields(sd SiteData) (map[string]json.RawMessage, error) {
+	msg, _, err := sd.encode()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(msg, &fields)
+	return fields, err
+}
+
+// jsonEqual compares values semantically, so formatting and string escapes don't count.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// WriteMarkdown writes the changes as a Markdown changelog section.
+func (c Changes) WriteMarkdown(w io.Writer, source string, when time.Time) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\nSource: %s\n\n", when.Format(time.RFC3339), source)
+	if c.Empty() {
+		b.WriteString("No changes.\n\n")
+	}
+	for _, name := range c.Added {
+		fmt.Fprintf(&b, "- Added `%s`\n", name)
+	}
+	for _, name := range c.Removed {
+		fmt.Fprintf(&b, "- Removed `%s`\n", name)
+	}
+	for _, m := range c.Modified {
+		fmt.Fprintf(&b, "- Modified `%s`: %s\n", m.Site, strings.Join(m.Fields, ", "))
+	}
+	if !c.Empty() {
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// AppendChangelog appends the changes to a Markdown changelog file.
+func (c Changes) AppendChangelog(path, source string, when time.Time) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteMarkdown(f, source, when); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
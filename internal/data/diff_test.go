@@ -0,0 +1,47 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := Parse([]byte(unsortedDatabase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := strings.NewReplacer(
+		// Formatting and escape-only changes are not modifications.
+		`"beta": {"errorType": "status_code", "url": "https://beta.example/{}", "urlMain": "https://beta.example/", "username_claimed": "bob"}`,
+		`"beta": {
+    "url": "https://beta.example/{}",
+    "errorType": "status_code",
+    "urlMain": "https://beta.example/",
+    "username_claimed": "bob"
+  }`,
+		`"errorMsg": "<title>Not Found</title>"`, `"errorMsg": "\u003ctitle\u003eNot Found\u003c/title\u003e"`,
+		// Real changes.
+		`"url": "https://zeta.example/{}"`, `"url": "https://zeta.example/u/{}"`,
+		`"tags": ["x", "y"]`, `"tags": ["x"], "regexCheck": "^[a-z]+$"`,
+		`"Alpha": {`, `"Gamma": {`,
+	).Replace(unsortedDatabase)
+	db, err := Parse([]byte(updated))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := Diff(old, db)
+	want := Changes{
+		Added:    []string{"Gamma"},
+		Removed:  []string{"Alpha"},
+		Modified: []SiteChange{{Site: "zeta", Fields: []string{"regexCheck", "tags", "url"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %+v, want %+v", got, want)
+	}
+
+	if c := Diff(old, old); !c.Empty() {
+		t.Errorf("Diff of a database with itself = %+v, want no changes", c)
+	}
+}
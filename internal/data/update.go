@@ -0,0 +1,276 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const SherlockDataURL = "https://raw.githubusercontent.com/sherlock-project/sherlock/refs/heads/master/sherlock_project/resources/data.json"
+
+// sherlockDataURLAt is the database URL pinned to a Sherlock commit (or tag).
+const sherlockDataURLAt = "https://raw.githubusercontent.com/sherlock-project/sherlock/%s/sherlock_project/resources/data.json"
+
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type UpdateConfig struct {
+	// Source is an http(s) URL, file:// URL or local path (default: Sherlock master).
+	Source string
+	// Commit pins the default Sherlock source to a commit; ignored when Source is set.
+	Commit string
+	// SHA256 is the expected hex digest of the downloaded file, if any.
+	SHA256 string
+
+	UserAgent string
+}
+
+// SourceURL returns where the database will be fetched from.
+func (c UpdateConfig) SourceURL() string {
+	switch {
+	case c.Source != "":
+		return c.Source
+	case c.Commit != "":
+		return fmt.Sprintf(sherlockDataURLAt, url.PathEscape(c.Commit))
+	default:
+		return SherlockDataURL
+	}
+}
+
+// updateMeta is stored next to the database (as <path>.meta) to make
+// later updates conditional. The name must not end in .json, or a database
+// directory would pick it up as a database file.
+type updateMeta struct {
+	Source       string    `json:"source"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+func metaPath(destPath string) string {
+	return destPath + ".meta"
+}
+
+// legacyMetaPath is where older versions kept the metadata.
+func legacyMetaPath(destPath string) string {
+	return destPath + ".meta.json"
+}
+
+func readMeta(destPath string) (updateMeta, bool) {
+	var meta updateMeta
+	raw, err := os.ReadFile(metaPath(destPath))
+	if os.IsNotExist(err) {
+		raw, err = os.ReadFile(legacyMetaPath(destPath))
+	}
+	if err != nil || json.Unmarshal(raw, &meta) != nil {
+		return updateMeta{}, false
+	}
+	return meta, true
+}
+
+func writeMeta(destPath string, meta updateMeta) error {
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(metaPath(destPath), append(b, '\n')); err != nil {
+		return err
+	}
+	if err := os.Remove(legacyMetaPath(destPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// LastUpdated returns when the database was last fetched (or found unchanged),
+// falling back to the file's modification time.
+func LastUpdated(destPath string) (time.Time, error) {
+	if meta, ok := readMeta(destPath); ok && !meta.FetchedAt.IsZero() {
+		return meta.FetchedAt, nil
+	}
+	info, err := os.Stat(destPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// Update fetches a new database, verifies it (checksum if configured, and that
+// every site has a usable url and errorType), backs up the current file to destPath+".bak",
+// and atomically replaces destPath. On any failure destPath is left untouched.
+//
+// Requests are conditional (ETag/If-Modified-Since) when the previous fetch
+// came from the same source; updated is false if the database was unchanged.
+func Update(ctx context.Context, client Doer, cfg UpdateConfig, destPath string) (updated bool, err error) {
+	prev, hasPrev := readMeta(destPath)
+	if _, err := os.Stat(destPath); err != nil || prev.Source != cfg.SourceURL() {
+		hasPrev = false
+	}
+
+	body, meta, err := fetchSource(ctx, client, cfg, prev, hasPrev)
+	if err != nil {
+		return false, err
+	}
+	if body == nil && cfg.SHA256 != "" {
+		// A 304 only vouches for the server's copy; the file on disk must
+		// still match the pinned checksum, otherwise fetch it again in full.
+		if cur, err := os.ReadFile(destPath); err != nil || verifySHA256(cur, cfg.SHA256) != nil {
+			if body, meta, err = fetchSource(ctx, client, cfg, prev, false); err != nil {
+				return false, err
+			}
+		}
+	}
+	meta.Source = cfg.SourceURL()
+	meta.FetchedAt = time.Now().UTC()
+
+	if body == nil {
+		// 304 Not Modified: keep the validators we already have.
+		meta.ETag, meta.LastModified = prev.ETag, prev.LastModified
+		return false, writeMeta(destPath, meta)
+	}
+
+	if cfg.SHA256 != "" {
+		if err := verifySHA256(body, cfg.SHA256); err != nil {
+			return false, err
+		}
+	}
+
+	if err := validateUpdate(body); err != nil {
+		return false, fmt.Errorf("invalid database from %s: %w", cfg.SourceURL(), err)
+	}
+
+	if cur, err := os.ReadFile(destPath); err == nil && bytes.Equal(cur, body) {
+		return false, writeMeta(destPath, meta)
+	}
+
+	if err := backup(destPath); err != nil {
+		return false, fmt.Errorf("backup %s: %w", destPath, err)
+	}
+	if err := writeFileAtomic(destPath, body); err != nil {
+		return false, err
+	}
+	return true, writeMeta(destPath, meta)
+}
+
+// verifySHA256 checks body against the hex digest want.
+func verifySHA256(body []byte, want string) error {
+	sum := sha256.Sum256(body)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, strings.TrimSpace(want)) {
+		return fmt.Errorf("checksum mismatch: got sha256 %s, want %s", got, want)
+	}
+	return nil
+}
+
+// validateUpdate rejects a database with no sites or with entries that can't be probed.
+func validateUpdate(body []byte) error {
+	db, err := Parse(body)
+	if err != nil {
+		return err
+	}
+	if len(db.Sites) == 0 {
+		return errors.New("no sites")
+	}
+
+	problems, err := Check(body)
+	if err != nil {
+		return err
+	}
+	// Other problems (a stale regexCheck, say) are common upstream and don't block an update.
+	var fatal []Problem
+	for _, p := range problems {
+		if p.Field == "" || p.Field == "url" || p.Field == "errorType" {
+			fatal = append(fatal, p)
+		}
+	}
+	switch len(fatal) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New(fatal[0].String())
+	default:
+		return fmt.Errorf("%s (and %d more)", fatal[0], len(fatal)-1)
+	}
+}
+
+// fetchSource returns the source's content, or a nil body if an HTTP source
+// answered 304 Not Modified to the conditional request built from prev.
+func fetchSource(ctx context.Context, client Doer, cfg UpdateConfig, prev updateMeta, conditional bool) ([]byte, updateMeta, error) {
+	src := cfg.SourceURL()
+
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		// Local file or mirror.
+		path := src
+		if err == nil && u.Scheme == "file" {
+			path = u.Path
+		}
+		body, err := os.ReadFile(path)
+		return body, updateMeta{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, updateMeta{}, err
+	}
+	if cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", cfg.UserAgent)
+	}
+	if conditional {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, updateMeta{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		return nil, updateMeta{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Read a small snippet for diagnostics.
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return nil, updateMeta{}, fmt.Errorf("download failed: %s (%s)", resp.Status, string(snippet))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, updateMeta{}, err
+	}
+	return body, updateMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// backup copies the current database (if any) to path+".bak".
+func backup(path string) error {
+	cur, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if prev, err := os.ReadFile(path + ".bak"); err == nil && bytes.Equal(prev, cur) {
+		return nil
+	}
+	return writeFileAtomic(path+".bak", cur)
+}
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDatabase = `{
+  "Example": {
+    "errorType": "status_code",
+    "url": "https://example.com/{}",
+    "urlMain": "https://example.com/",
+    "username_claimed": "alice"
+  }
+}
+`
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// databaseServer serves body with an ETag and answers matching conditional requests with 304.
+func databaseServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	etag := `"` + sha256Hex([]byte(body))[:16] + `"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUpdateConditional(t *testing.T) {
+	srv := databaseServer(t, testDatabase)
+	dest := filepath.Join(t.TempDir(), "data.json")
+	cfg := UpdateConfig{Source: srv.URL, SHA256: sha256Hex([]byte(testDatabase))}
+
+	if updated, err := Update(context.Background(), srv.Client(), cfg, dest); err != nil || !updated {
+		t.Fatalf("first update: updated=%t err=%v", updated, err)
+	}
+	if updated, err := Update(context.Background(), srv.Client(), cfg, dest); err != nil || updated {
+		t.Fatalf("second update: updated=%t err=%v, want not modified", updated, err)
+	}
+
+	// The server still answers 304, but the local copy no longer matches the pin.
+	if err := os.WriteFile(dest, []byte(`{"Edited": null}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := Update(context.Background(), srv.Client(), cfg, dest); err != nil || !updated {
+		t.Fatalf("update over edited file: updated=%t err=%v, want a full re-download", updated, err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != testDatabase {
+		t.Errorf("database = %q, want the pinned download", got)
+	}
+
+	cfg.SHA256 = strings.Repeat("0", 64)
+	if _, err := Update(context.Background(), srv.Client(), cfg, dest); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("update with a wrong pin: err=%v, want checksum mismatch", err)
+	}
+}
+
+func TestUpdateRejectsInvalidDatabase(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"not json", `<html>`, "invalid database"},
+		{"no sites", `{}`, "no sites"},
+		{"empty entry", `{"A": {}}`, "A: errorType: required field is missing (and 1 more)"},
+		{"bad errorType", strings.Replace(testDatabase, "status_code", "guess", 1), "errorType"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := databaseServer(t, tt.body)
+			dest := filepath.Join(t.TempDir(), "data.json")
+			if err := os.WriteFile(dest, []byte(testDatabase), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Update(context.Background(), srv.Client(), UpdateConfig{Source: srv.URL}, dest)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.want)
+			}
+			if got, _ := os.ReadFile(dest); string(got) != testDatabase {
+				t.Errorf("database was modified: %q", got)
+			}
+		})
+	}
+}
+
+func TestShippedDatabaseIsValidUpdate(t *testing.T) {
+	body, err := os.ReadFile("../../data.json")
+	if err != nil {
+		t.Skip(err)
+	}
+	if err := validateUpdate(body); err != nil {
+		t.Errorf("shipped data.json rejected: %v", err)
+	}
+}
+
+func TestUpdateIntoDatabaseDirectory(t *testing.T) {
+	srv := databaseServer(t, testDatabase)
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "upstream.json")
+	// Metadata left behind by older versions is migrated away.
+	if err := os.WriteFile(legacyMetaPath(dest), []byte(`{"source":"elsewhere"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if _, err := Update(context.Background(), srv.Client(), UpdateConfig{Source: srv.URL}, dest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(legacyMetaPath(dest)); !os.IsNotExist(err) {
+		t.Errorf("legacy metadata file still exists: %v", err)
+	}
+
+	db, err := LoadMerged(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := siteNames(db); strings.Join(got, ",") != "Example" {
+		t.Errorf("sites = %v, want only the updated database", got)
+	}
+}
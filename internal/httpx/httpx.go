@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/net/proxy"
@@ -82,3 +83,15 @@ func NewRequest(ctx context.Context, method, rawURL string, body io.Reader, user
 	}
 	return req, nil
 }
+
+// SetHeaders applies headers to req, overriding any existing values.
+// A "Host" header is applied to req.Host since net/http ignores it in req.Header.
+func SetHeaders(req *http.Request, headers map[string]string) {
+	for k, v := range headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+}
@@ -0,0 +1,92 @@
+package httpx
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimiter paces requests with a token bucket per host. A nil
+// *HostLimiter doesn't limit anything.
+type HostLimiter struct {
+	rate  float64 // default requests per second; <= 0 means unlimited
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewHostLimiter returns a limiter allowing rate requests per second to each
+// host, with bursts of up to burst requests.
+func NewHostLimiter(rate float64, burst int) *HostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &HostLimiter{rate: rate, burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// Wait blocks until a request to host may be sent or ctx is done.
+// A positive rate overrides the default for this host.
+func (l *HostLimiter) Wait(ctx context.Context, host string, rate float64) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	if rate <= 0 {
+		rate = l.rate
+	}
+	if rate <= 0 {
+		return ctx.Err()
+	}
+
+	delay := l.reserve(strings.ToLower(host), rate)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.cancel(strings.ToLower(host))
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token from host's bucket and returns how long the caller
+// has to wait for it. Tokens may go negative, queueing callers in order.
+func (l *HostLimiter) reserve(host string, rate float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b := l.buckets[host]
+	if b == nil {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[host] = b
+	}
+	b.tokens = min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// cancel returns the token of a reservation that won't be used.
+func (l *HostLimiter) cancel(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b := l.buckets[host]; b != nil {
+		b.tokens = min(float64(l.burst), b.tokens+1)
+	}
+}
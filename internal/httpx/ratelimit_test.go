@@ -0,0 +1,69 @@
+package httpx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterReserve(t *testing.T) {
+	l := NewHostLimiter(10, 2)
+
+	// The burst goes out at once, then requests are spaced 1/rate apart.
+	want := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := l.reserve("example.com", 10); !near(got, w) {
+			t.Errorf("reserve #%d = %s, want %s", i, got, w)
+		}
+	}
+
+	// Hosts have separate buckets.
+	if got := l.reserve("other.com", 10); got != 0 {
+		t.Errorf("reserve on another host = %s, want 0", got)
+	}
+
+	// A per-site rate applies to its own reservations.
+	l = NewHostLimiter(10, 1)
+	l.reserve("slow.com", 1)
+	if got := l.reserve("slow.com", 1); !near(got, time.Second) {
+		t.Errorf("reserve at 1/s = %s, want 1s", got)
+	}
+
+	// A cancelled reservation gives its token back.
+	l = NewHostLimiter(10, 1)
+	l.reserve("example.com", 10)
+	l.reserve("example.com", 10)
+	l.cancel("example.com")
+	if got := l.reserve("example.com", 10); !near(got, 100*time.Millisecond) {
+		t.Errorf("reserve after cancel = %s, want 100ms", got)
+	}
+}
+
+func TestHostLimiterWait(t *testing.T) {
+	var nilLimiter *HostLimiter
+	if err := nilLimiter.Wait(context.Background(), "example.com", 0); err != nil {
+		t.Errorf("nil limiter: %v", err)
+	}
+
+	unlimited := NewHostLimiter(0, 1)
+	start := time.Now()
+	for range 100 {
+		_ = unlimited.Wait(context.Background(), "example.com", 0)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("unlimited limiter waited %s", d)
+	}
+
+	l := NewHostLimiter(1, 1)
+	_ = l.Wait(context.Background(), "example.com", 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "example.com", 0); err == nil {
+		t.Error("Wait returned before the deadline, want context error")
+	}
+}
+
+// near allows for the time that passes between reservations.
+func near(got, want time.Duration) bool {
+	return got <= want && got >= want-20*time.Millisecond
+}
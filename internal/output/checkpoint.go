@@ -0,0 +1,205 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+// Checkpoint is an append-only NDJSON log of completed (username, site)
+// results, used to resume an interrupted scan. Several runs for different
+// usernames can share one checkpoint; each keeps only its own progress.
+type Checkpoint struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]map[string]scan.Result // username -> site -> result
+}
+
+// OpenCheckpoint opens the checkpoint at path. With resume, results recorded
+// for usernames by a previous run are loaded and kept; otherwise they are
+// discarded. Progress recorded for other usernames is always left alone.
+func OpenCheckpoint(path string, resume bool, usernames []string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, done: make(map[string]map[string]scan.Result)}
+
+	if !resume {
+		if err := c.rewrite(usernames); err != nil {
+			return nil, err
+		}
+	}
+	partial, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if partial {
+		// Terminate the truncated line so new records start on their own.
+		if _, err := f.Write([]byte("\n")); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	c.f = f
+	return c, nil
+}
+
+// load reads the recorded results. It returns whether the file ends in the
+// middle of a line, as left behind by a run killed mid-write.
+func (c *Checkpoint) load() (partial bool, err error) {
+	b, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for i, line := range bytes.Split(b, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			// A truncated line from a killed run; skip it.
+			continue
+		}
+		if rec.Username == "" || rec.Site == "" {
+			return false, fmt.Errorf("%s:%d: record without username or site", c.path, i+1)
+		}
+		if rec.Status == scan.StatusError {
+			// Written by older versions; errors are worth another try.
+			continue
+		}
+		c.add(rec.Result())
+	}
+	return len(b) > 0 && b[len(b)-1] != '\n', nil
+}
+
+// rewrite drops the records of usernames from the file, deleting it when
+// nothing is left. Truncated lines are dropped as well.
+func (c *Checkpoint) rewrite(usernames []string) error {
+	b, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	drop := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		drop[username] = true
+	}
+	var kept bytes.Buffer
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		var rec Record
+		if json.Unmarshal(line, &rec) != nil || drop[rec.Username] {
+			continue
+		}
+		kept.Write(line)
+		kept.WriteByte('\n')
+	}
+
+	if kept.Len() == 0 {
+		return os.Remove(c.path)
+	}
+	if kept.Len() == len(b) {
+		return nil
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, kept.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func (c *Checkpoint) add(result scan.Result) {
+	sites := c.done[result.Username]
+	if sites == nil {
+		sites = make(map[string]scan.Result)
+		c.done[result.Username] = sites
+	}
+	sites[result.Site] = result
+}
+
+// Done reports whether a result for (username, site) has been recorded.
+func (c *Checkpoint) Done(username, site string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.done[username][site]
+	return ok
+}
+
+// Results returns the recorded results of username, in no particular order.
+func (c *Checkpoint) Results(username string) []scan.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]scan.Result, 0, len(c.done[username]))
+	for _, r := range c.done[username] {
+		out = append(out, r)
+	}
+	return out
+}
+
+// Record appends a completed result to the checkpoint file. Inconclusive
+// results (errors and downloads cut short by cancellation) are not recorded,
+// so --resume tries them again.
+func (c *Checkpoint) Record(result scan.Result) error {
+	if !conclusive(result) {
+		return nil
+	}
+	b, err := marshalRecord(NewRecord(result), false)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	c.add(result)
+	return nil
+}
+
+func conclusive(result scan.Result) bool {
+	if result.Err != nil {
+		return false
+	}
+	if dl := result.Download; dl != nil &&
+		(errors.Is(dl.Err, context.Canceled) || errors.Is(dl.Err, context.DeadlineExceeded)) {
+		return false
+	}
+	return true
+}
+
+// Close closes the checkpoint, keeping it on disk for --resume.
+func (c *Checkpoint) Close() error {
+	return c.f.Close()
+}
+
+// Remove closes the checkpoint and deletes the progress of usernames, which
+// finished in this run. The file itself is deleted once no progress is left.
+func (c *Checkpoint) Remove(usernames ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.f.Close(); err != nil {
+		return err
+	}
+	for _, username := range usernames {
+		delete(c.done, username)
+	}
+	return c.rewrite(usernames)
+}
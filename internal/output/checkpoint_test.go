@@ -0,0 +1,145 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+func openCheckpoint(t *testing.T, path string, resume bool, usernames ...string) *Checkpoint {
+	t.Helper()
+	c, err := OpenCheckpoint(path, resume, usernames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func record(t *testing.T, c *Checkpoint, results ...scan.Result) {
+	t.Helper()
+	for _, res := range results {
+		if err := c.Record(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".checkpoint.ndjson")
+
+	c := openCheckpoint(t, path, false, "alice")
+	record(t, c,
+		scan.Result{Username: "alice", Site: "Found", Exists: true, Link: "https://found.example/alice"},
+		scan.Result{Username: "alice", Site: "Missing"},
+		scan.Result{Username: "alice", Site: "Skipped", Skipped: true},
+		scan.Result{Username: "alice", Site: "Broken", Err: errors.New("connection refused")},
+		scan.Result{Username: "alice", Site: "Cut", Exists: true, Download: &scan.Download{Dir: "d", Err: context.Canceled}},
+		scan.Result{Username: "alice", Site: "Failed", Exists: true, Download: &scan.Download{Dir: "d", Err: errors.New("404")}},
+	)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = openCheckpoint(t, path, true, "alice")
+	defer c.Close()
+	tests := []struct {
+		site string
+		want bool
+	}{
+		{"Found", true},
+		{"Missing", true},
+		{"Skipped", true},
+		{"Failed", true},
+		{"Broken", false},
+		{"Cut", false},
+	}
+	for _, tt := range tests {
+		if got := c.Done("alice", tt.site); got != tt.want {
+			t.Errorf("Done(alice, %s) = %t, want %t", tt.site, got, tt.want)
+		}
+	}
+	if got := len(c.Results("alice")); got != 4 {
+		t.Errorf("loaded %d results, want 4", got)
+	}
+	for _, res := range c.Results("alice") {
+		if res.Site == "Found" && (!res.Exists || res.Link != "https://found.example/alice") {
+			t.Errorf("Found came back as %+v", res)
+		}
+	}
+}
+
+func TestCheckpointPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".checkpoint.ndjson")
+	c := openCheckpoint(t, path, false)
+	record(t, c, scan.Result{Username: "alice", Site: "A"})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A run killed in the middle of a write.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"username":"alice","site":"B","sta`); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	c = openCheckpoint(t, path, true, "alice")
+	if !c.Done("alice", "A") || c.Done("alice", "B") {
+		t.Fatalf("after a partial line: A=%t B=%t, want only A", c.Done("alice", "A"), c.Done("alice", "B"))
+	}
+	record(t, c, scan.Result{Username: "alice", Site: "B"})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = openCheckpoint(t, path, true, "alice")
+	defer c.Close()
+	if !c.Done("alice", "A") || !c.Done("alice", "B") {
+		t.Errorf("record after a partial line was lost: A=%t B=%t", c.Done("alice", "A"), c.Done("alice", "B"))
+	}
+}
+
+func TestCheckpointKeepsOtherUsernames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".checkpoint.ndjson")
+	c := openCheckpoint(t, path, false, "alice", "bob")
+	record(t, c, scan.Result{Username: "alice", Site: "A"}, scan.Result{Username: "bob", Site: "A"})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh run for alice starts her over but keeps bob's progress.
+	c = openCheckpoint(t, path, false, "alice")
+	if c.Done("alice", "A") || !c.Done("bob", "A") {
+		t.Errorf("fresh run for alice: alice=%t bob=%t, want only bob", c.Done("alice", "A"), c.Done("bob", "A"))
+	}
+	record(t, c, scan.Result{Username: "alice", Site: "B"})
+	if err := c.Remove("alice"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("checkpoint deleted with bob's progress in it: %v", err)
+	}
+	if strings.Contains(string(b), `"alice"`) || !strings.Contains(string(b), `"bob"`) {
+		t.Errorf("checkpoint after alice finished:\n%s", b)
+	}
+
+	c = openCheckpoint(t, path, true, "bob")
+	if !c.Done("bob", "A") {
+		t.Error("bob's progress was lost")
+	}
+	if err := c.Remove("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint still exists after every username finished: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+var csvHeader = []string{"username", "site", "status", "url", "http_status", "error"}
+
+// WriteCSV writes one row per result.
+func WriteCSV(w io.Writer, results []scan.Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		httpStatus := ""
+		if r.StatusCode != 0 {
+			httpStatus = strconv.Itoa(r.StatusCode)
+		}
+		errText := ""
+		if r.Err != nil {
+			errText = r.Err.Error()
+		}
+
+		if err := cw.Write([]string{r.Username, r.Site, r.Status(), r.ProfileURL(), httpStatus, errText}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteCSVFile writes results to path as CSV.
+func WriteCSVFile(path string, results []scan.Result) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := WriteCSV(f, results); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
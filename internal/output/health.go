@@ -0,0 +1,171 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+// HealthReport is the structured result of --test.
+type HealthReport struct {
+	Generated      time.Time      `json:"generated"`
+	Database       string         `json:"database"`
+	DatabaseSHA256 string         `json:"database_sha256,omitempty"`
+	WithTor        bool           `json:"tor"`
+	Summary        map[string]int `json:"summary"`
+	Sites          []SiteHealth   `json:"sites"`
+}
+
+type SiteHealth struct {
+	Site      string      `json:"site"`
+	Status    string      `json:"status"`
+	Claimed   ProbeHealth `json:"claimed"`
+	Unclaimed ProbeHealth `json:"unclaimed"`
+}
+
+type ProbeHealth struct {
+	Username   string `json:"username"`
+	Exists     bool   `json:"exists"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	FinalURL   string `json:"final_url,omitempty"`
+	Rule       string `json:"rule,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ElapsedMS  int64  `json:"elapsed_ms"`
+}
+
+// Add records a validation; sites are kept sorted by name.
+func (r *HealthReport) Add(v scan.Validation) {
+	if r.Summary == nil {
+		r.Summary = map[string]int{
+			scan.HealthPassing:       0,
+			scan.HealthFalsePositive: 0,
+			scan.HealthFalseNegative: 0,
+			scan.HealthErrored:       0,
+		}
+	}
+
+	sh := SiteHealth{
+		Site:      v.Site,
+		Status:    v.Health(),
+		Claimed:   newProbeHealth(v.Used),
+		Unclaimed: newProbeHealth(v.Unused),
+	}
+	r.Summary[sh.Status]++
+
+	i := sort.Search(len(r.Sites), func(i int) bool { return r.Sites[i].Site >= sh.Site })
+	r.Sites = append(r.Sites, SiteHealth{})
+	copy(r.Sites[i+1:], r.Sites[i:])
+	r.Sites[i] = sh
+}
+
+func newProbeHealth(res scan.Result) ProbeHealth {
+	p := ProbeHealth{
+		Username:   res.Username,
+		Exists:     res.Exists,
+		HTTPStatus: res.StatusCode,
+		FinalURL:   res.FinalURL,
+		Rule:       res.Rule,
+		ElapsedMS:  res.Elapsed.Milliseconds(),
+	}
+	if res.Err != nil {
+		p.Error = res.Err.Error()
+	}
+	return p
+}
+
+// Passing returns the names of sites that passed validation.
+func (r *HealthReport) Passing() []string {
+	var out []string
+	for _, s := range r.Sites {
+		if s.Status == scan.HealthPassing {
+			out = append(out, s.Site)
+		}
+	}
+	return out
+}
+
+func WriteHealthJSON(w io.Writer, r *HealthReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func WriteHealthMarkdown(w io.Writer, r *HealthReport) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Site health report\n\n")
+	fmt.Fprintf(&b, "- Generated: %s\n", r.Generated.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Database: `%s`\n", r.Database)
+	if r.DatabaseSHA256 != "" {
+		fmt.Fprintf(&b, "- Database SHA-256: `%s`\n", r.DatabaseSHA256)
+	}
+	fmt.Fprintf(&b, "- Tor: %t\n\n", r.WithTor)
+
+	fmt.Fprintf(&b, "| Status | Sites |\n|---|---|\n")
+	for _, status := range []string{scan.HealthPassing, scan.HealthFalsePositive, scan.HealthFalseNegative, scan.HealthErrored} {
+		fmt.Fprintf(&b, "| %s | %d |\n", status, r.Summary[status])
+	}
+
+	fmt.Fprintf(&b, "\n## Failing sites\n\n")
+	fmt.Fprintf(&b, "| Site | Status | Claimed | Unclaimed | Error |\n|---|---|---|---|---|\n")
+	for _, s := range r.Sites {
+		if s.Status == scan.HealthPassing {
+			continue
+		}
+		errText := s.Claimed.Error
+		if errText == "" {
+			errText = s.Unclaimed.Error
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			markdownCell(s.Site), s.Status, probeCell(s.Claimed), probeCell(s.Unclaimed), markdownCell(errText))
+	}
+
+	fmt.Fprintf(&b, "\n## Passing sites\n\n")
+	for _, name := range r.Passing() {
+		fmt.Fprintf(&b, "- %s\n", name)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHealthFiles writes the report as JSON and Markdown next to each other.
+func WriteHealthFiles(jsonPath, markdownPath string, r *HealthReport) error {
+	for path, write := range map[string]func(io.Writer, *HealthReport) error{
+		jsonPath:     WriteHealthJSON,
+		markdownPath: WriteHealthMarkdown,
+	} {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		if err := write(f, r); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func probeCell(p ProbeHealth) string {
+	status := "-"
+	if p.HTTPStatus != 0 {
+		status = fmt.Sprint(p.HTTPStatus)
+	}
+	return fmt.Sprintf("`%s` exists=%t status=%s %dms", markdownCell(p.Username), p.Exists, status, p.ElapsedMS)
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"html/template"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+// ReportMeta describes the run an HTML report was generated from.
+type ReportMeta struct {
+	Username       string
+	Database       string
+	DatabaseSHA256 string
+	WithTor        bool
+	Generated      time.Time
+	// Incomplete marks a report written after the scan was interrupted.
+	Incomplete bool
+}
+
+type reportData struct {
+	ReportMeta
+
+	Total    int
+	Found    []scan.Result
+	NotFound []scan.Result
+	Errors   []scan.Result
+	Skipped  []scan.Result
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	// category is the site's database "category", or "-" if it has none.
+	"category": func(r scan.Result) string {
+		if r.Category == "" {
+			return "-"
+		}
+		return r.Category
+	},
+	"timestamp": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Investigo report: {{.Username}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+td.meta { width: 12em; color: #555; }
+code { font-size: 0.9em; word-break: break-all; }
+.nsfw { color: #b00; font-weight: bold; }
+.err { color: #b00; }
+summary { cursor: pointer; font-weight: bold; margin-top: 1.5em; }
+</style>
+</head>
+<body>
+<h1>Investigo report: {{.Username}}</h1>
+
+<table>
+{{if .Incomplete}}<tr><td class="meta">Status</td><td class="err">Incomplete: the scan was interrupted before every site was checked</td></tr>
+{{end}}<tr><td class="meta">Generated</td><td>{{timestamp .Generated}}</td></tr>
+<tr><td class="meta">Database</td><td><code>{{.Database}}</code></td></tr>
+<tr><td class="meta">Database SHA-256</td><td><code>{{.DatabaseSHA256}}</code></td></tr>
+<tr><td class="meta">Tor</td><td>{{if .WithTor}}on{{else}}off{{end}}</td></tr>
+<tr><td class="meta">Sites checked</td><td>{{.Total}}</td></tr>
+<tr><td class="meta">Summary</td><td>{{len .Found}} found, {{len .NotFound}} not found, {{len .Errors}} errors, {{len .Skipped}} skipped</td></tr>
+</table>
+
+<h2>Found profiles ({{len .Found}})</h2>
+{{if .Found}}
+<table>
+<tr><th>Site</th><th>Category</th><th>Profile</th></tr>
+{{range .Found}}<tr><td>{{.Site}}</td><td>{{category .}}{{if .NSFW}} <span class="nsfw">NSFW</span>{{end}}</td><td><a href="{{.Link}}" rel="noreferrer noopener">{{.Link}}</a></td></tr>
+{{end}}</table>
+{{else}}
+<p>No profiles found.</p>
+{{end}}
+
+{{if .Errors}}
+<details>
+<summary>Errors ({{len .Errors}})</summary>
+<table>
+<tr><th>Site</th><th>Error</th></tr>
+{{range .Errors}}<tr><td>{{.Site}}</td><td class="err"><code>{{.Err}}</code></td></tr>
+{{end}}</table>
+</details>
+{{end}}
+
+{{if .Skipped}}
+<details>
+<summary>Skipped ({{len .Skipped}})</summary>
+<table>
+<tr><th>Site</th><th>Category</th></tr>
+{{range .Skipped}}<tr><td>{{.Site}}</td><td>{{category .}}{{if .NSFW}} <span class="nsfw">NSFW</span>{{end}}</td></tr>
+{{end}}</table>
+</details>
+{{end}}
+
+{{if .NotFound}}
+<details>
+<summary>Not found ({{len .NotFound}})</summary>
+<table>
+<tr><th>Site</th><th>Category</th></tr>
+{{range .NotFound}}<tr><td>{{.Site}}</td><td>{{category .}}{{if .NSFW}} <span class="nsfw">NSFW</span>{{end}}</td></tr>
+{{end}}</table>
+</details>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTMLReport renders a self-contained HTML report (no external assets).
+func WriteHTMLReport(w io.Writer, meta ReportMeta, results []scan.Result) error {
+	rd := reportData{ReportMeta: meta, Total: len(results)}
+	for _, r := range results {
+		switch r.Status() {
+		case scan.StatusFound:
+			rd.Found = append(rd.Found, r)
+		case scan.StatusError:
+			rd.Errors = append(rd.Errors, r)
+		case scan.StatusSkipped:
+			rd.Skipped = append(rd.Skipped, r)
+		default:
+			rd.NotFound = append(rd.NotFound, r)
+		}
+	}
+	return reportTemplate.Execute(w, rd)
+}
+
+// WriteHTMLReportFile writes the HTML report to path.
+func WriteHTMLReportFile(path string, meta ReportMeta, results []scan.Result) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := WriteHTMLReport(f, meta, results); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,31 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+func TestWriteHTMLReportCategories(t *testing.T) {
+	results := []scan.Result{
+		{Site: "GitHub", Category: "coding", Exists: true, Link: "https://www.github.com/alice"},
+		{Site: "Plain", Exists: true, Link: "https://plain.example/alice"},
+		{Site: "Adult", Category: "video", NSFW: true, Exists: true, Link: "https://adult.example/alice"},
+	}
+	var b strings.Builder
+	if err := WriteHTMLReport(&b, ReportMeta{Username: "alice"}, results); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		`<td>GitHub</td><td>coding</td>`,
+		`<td>Plain</td><td>-</td>`,
+		`<td>Adult</td><td>video <span class="nsfw">NSFW</span></td>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+}
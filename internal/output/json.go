@@ -0,0 +1,183 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+// Record is the machine-readable form of a scan.Result.
+type Record struct {
+	Username string `json:"username"`
+	Site     string `json:"site"`
+
+	URLTemplate   string `json:"url_template"`
+	ProbeTemplate string `json:"probe_template,omitempty"`
+	Link          string `json:"link,omitempty"`
+
+	Status     string `json:"status"`
+	Exists     bool   `json:"exists"`
+	Proxied    bool   `json:"proxied"`
+	NSFW       bool   `json:"nsfw"`
+	Category   string `json:"category,omitempty"`
+	Error      string `json:"error,omitempty"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	FinalURL   string `json:"final_url,omitempty"`
+	BodyBytes  int64  `json:"body_bytes,omitempty"`
+	Rule       string `json:"rule,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+	ElapsedMS  int64  `json:"elapsed_ms"`
+	WaitedMS   int64  `json:"waited_ms,omitempty"`
+
+	Download *DownloadRecord `json:"download,omitempty"`
+}
+
+type DownloadRecord struct {
+	Dir   string `json:"dir"`
+	Error string `json:"error,omitempty"`
+}
+
+func NewRecord(result scan.Result) Record {
+	rec := Record{
+		Username:      result.Username,
+		Site:          result.Site,
+		URLTemplate:   result.URLTemplate,
+		ProbeTemplate: result.ProbeTemplate,
+		Link:          result.Link,
+		Status:        result.Status(),
+		Exists:        result.Exists,
+		Proxied:       result.Proxied,
+		NSFW:          result.NSFW,
+		Category:      result.Category,
+		HTTPStatus:    result.StatusCode,
+		FinalURL:      result.FinalURL,
+		BodyBytes:     result.BodyBytes,
+		Rule:          result.Rule,
+		Attempts:      result.Attempts,
+		ElapsedMS:     result.Elapsed.Milliseconds(),
+		WaitedMS:      result.Waited.Milliseconds(),
+	}
+	if result.Err != nil {
+		rec.Error = result.Err.Error()
+	}
+	if dl := result.Download; dl != nil {
+		rec.Download = &DownloadRecord{Dir: dl.Dir}
+		if dl.Err != nil {
+			rec.Download.Error = dl.Err.Error()
+		}
+	}
+	return rec
+}
+
+// Result converts a record back into a scan.Result. Errors come back as
+// plain error values carrying the recorded message.
+func (rec Record) Result() scan.Result {
+	result := scan.Result{
+		Username:      rec.Username,
+		Site:          rec.Site,
+		URLTemplate:   rec.URLTemplate,
+		ProbeTemplate: rec.ProbeTemplate,
+		Link:          rec.Link,
+		Exists:        rec.Exists,
+		Proxied:       rec.Proxied,
+		NSFW:          rec.NSFW,
+		Category:      rec.Category,
+		Skipped:       rec.Status == scan.StatusSkipped,
+		StatusCode:    rec.HTTPStatus,
+		FinalURL:      rec.FinalURL,
+		BodyBytes:     rec.BodyBytes,
+		Rule:          rec.Rule,
+		Attempts:      rec.Attempts,
+		Elapsed:       time.Duration(rec.ElapsedMS) * time.Millisecond,
+		Waited:        time.Duration(rec.WaitedMS) * time.Millisecond,
+	}
+	if rec.Error != "" {
+		result.Err = errors.New(rec.Error)
+	}
+	if dl := rec.Download; dl != nil {
+		result.Download = &scan.Download{Dir: dl.Dir}
+		if dl.Error != "" {
+			result.Download.Err = errors.New(dl.Error)
+		}
+	}
+	return result
+}
+
+// JSONPrinter streams records either as one JSON array or as
+// newline-delimited JSON (one object per line).
+type JSONPrinter struct {
+	w      io.Writer
+	ndjson bool
+	count  int
+	err    error
+}
+
+func NewJSONPrinter(w io.Writer, ndjson bool) *JSONPrinter {
+	return &JSONPrinter{w: w, ndjson: ndjson}
+}
+
+func (p *JSONPrinter) Result(result scan.Result) {
+	if p.err != nil {
+		return
+	}
+
+	b, err := marshalRecord(NewRecord(result), !p.ndjson)
+	if err != nil {
+		p.err = err
+		return
+	}
+
+	switch {
+	case p.ndjson:
+		_, p.err = p.w.Write(append(b, '\n'))
+	case p.count == 0:
+		_, p.err = p.w.Write(append([]byte("[\n  "), b...))
+	default:
+		_, p.err = p.w.Write(append([]byte(",\n  "), b...))
+	}
+	p.count++
+}
+
+// Close terminates the JSON array (if any) and returns the first write error.
+func (p *JSONPrinter) Close() error {
+	if p.err != nil || p.ndjson {
+		return p.err
+	}
+	if p.count == 0 {
+		_, p.err = io.WriteString(p.w, "[]\n")
+	} else {
+		_, p.err = io.WriteString(p.w, "\n]\n")
+	}
+	return p.err
+}
+
+// WriteJSON writes results to path as a JSON array or as NDJSON.
+func WriteJSON(path string, ndjson bool, results []scan.Result) error {
+	var buf bytes.Buffer
+	p := NewJSONPrinter(&buf, ndjson)
+	for _, r := range results {
+		p.Result(r)
+	}
+	if err := p.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+func marshalRecord(rec Record, indent bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if indent {
+		enc.SetIndent("  ", "  ")
+	}
+	if err := enc.Encode(rec); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
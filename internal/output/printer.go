@@ -1,9 +1,11 @@
 package output
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 
@@ -34,27 +36,44 @@ func (p *Printer) Logger() *log.Logger {
 	return p.logger
 }
 
+// SetPrefix prefixes every stdout line, e.g. with the username when
+// several users are scanned at once. File output is not prefixed.
+func (p *Printer) SetPrefix(prefix string) {
+	p.logger.SetPrefix(prefix)
+}
+
 func (p *Printer) Result(result scan.Result) {
 	// File output is always plain.
 	if p.stream != nil {
 		if result.Exists {
-			p.stream.Printf("[%s] %s: %s", "+", result.Site, result.Link)
+			p.stream.Printf("[%s] %s: %s", "+", siteLabel(result), result.Link)
+			p.streamDetails(result)
+			if dl := result.Download; dl != nil {
+				if dl.Err != nil {
+					p.stream.Printf("    [%s] download failed: %s", "!", dl.Err.Error())
+				} else {
+					p.stream.Printf("    [%s] downloaded to %s", "+", dl.Dir)
+				}
+			}
 		} else if p.verbose {
 			if result.Err != nil {
 				p.stream.Printf("[%s] %s: ERROR: %s", "!", result.Site, result.Err.Error())
 			} else {
 				p.stream.Printf("[%s] %s: %s", "-", result.Site, "Not Found!")
 			}
+			p.streamDetails(result)
 		}
 	}
 
 	// Stdout output (colored or not).
 	if result.Exists {
 		if p.noColor {
-			p.logger.Printf("[%s] %s: %s", "+", result.Site, result.Link)
+			p.logger.Printf("[%s] %s: %s", "+", siteLabel(result), result.Link)
 		} else {
-			p.logger.Printf("[%s] %s: %s", color.HiGreenString("+"), color.HiWhiteString(result.Site), result.Link)
+			p.logger.Printf("[%s] %s: %s", color.HiGreenString("+"), color.HiWhiteString(siteLabel(result)), result.Link)
 		}
+		p.details(result)
+		p.download(result.Download)
 		return
 	}
 
@@ -70,6 +89,7 @@ func (p *Printer) Result(result scan.Result) {
 					color.HiRedString(result.Err.Error()),
 				)
 			}
+			p.details(result)
 			return
 		}
 
@@ -78,5 +98,83 @@ func (p *Printer) Result(result scan.Result) {
 		} else {
 			p.logger.Printf("[%s] %s: %s", color.HiRedString("-"), result.Site, color.HiYellowString("Not Found!"))
 		}
+		p.details(result)
+	}
+}
+
+// details prints probe diagnostics (verbose only).
+func (p *Printer) details(result scan.Result) {
+	d := detailLine(result)
+	if !p.verbose || d == "" {
+		return
+	}
+	if p.noColor {
+		p.logger.Print(d)
+	} else {
+		p.logger.Print(color.HiBlackString(d))
+	}
+}
+
+func (p *Printer) streamDetails(result scan.Result) {
+	if d := detailLine(result); p.verbose && d != "" {
+		p.stream.Print(d)
+	}
+}
+
+func detailLine(result scan.Result) string {
+	var parts []string
+	if result.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("status=%d", result.StatusCode))
+	}
+	if result.Elapsed > 0 {
+		parts = append(parts, "time="+result.Elapsed.Round(time.Millisecond).String())
+	}
+	if result.Waited >= time.Millisecond {
+		parts = append(parts, "wait="+result.Waited.Round(time.Millisecond).String())
+	}
+	if result.Attempts > 1 {
+		parts = append(parts, fmt.Sprintf("attempts=%d", result.Attempts))
+	}
+	if result.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("size=%dB", result.BodyBytes))
+	}
+	if result.FinalURL != "" {
+		parts = append(parts, "final="+result.FinalURL)
+	}
+	if result.Rule != "" {
+		parts = append(parts, "rule="+result.Rule)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "    " + strings.Join(parts, " ")
+}
+
+func (p *Printer) download(dl *scan.Download) {
+	if dl == nil {
+		return
+	}
+
+	if dl.Err != nil {
+		if p.noColor {
+			p.logger.Printf("    [%s] download failed: %s", "!", dl.Err.Error())
+		} else {
+			p.logger.Printf("    [%s] %s: %s", color.HiRedString("!"), color.HiMagentaString("download failed"), color.HiRedString(dl.Err.Error()))
+		}
+		return
+	}
+
+	if p.noColor {
+		p.logger.Printf("    [%s] downloaded to %s", "+", dl.Dir)
+	} else {
+		p.logger.Printf("    [%s] downloaded to %s", color.HiGreenString("+"), color.HiWhiteString(dl.Dir))
+	}
+}
+
+// siteLabel tags NSFW sites so reports can be redacted.
+func siteLabel(result scan.Result) string {
+	if result.NSFW {
+		return result.Site + " (NSFW)"
 	}
+	return result.Site
 }
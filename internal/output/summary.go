@@ -0,0 +1,60 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/tdh8316/Investigo/internal/scan"
+)
+
+// Per-user progress in a RunSummary.
+const (
+	UserComplete   = "complete"
+	UserIncomplete = "incomplete"
+	UserNotStarted = "not started"
+)
+
+// RunSummary records how far a scan run got, so scripts can tell a partial
+// run from a complete one.
+type RunSummary struct {
+	Started     time.Time     `json:"started"`
+	Finished    time.Time     `json:"finished"`
+	Interrupted bool          `json:"interrupted"`
+	Users       []UserSummary `json:"users"`
+}
+
+type UserSummary struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	Sites    int    `json:"sites"`
+	Scanned  int    `json:"scanned"`
+	Found    int    `json:"found"`
+	Errors   int    `json:"errors"`
+}
+
+// NewUserSummary counts the results collected for username out of sites.
+func NewUserSummary(username string, sites int, results []scan.Result) UserSummary {
+	us := UserSummary{Username: username, Status: UserComplete, Sites: sites, Scanned: len(results)}
+	if us.Scanned < sites {
+		us.Status = UserIncomplete
+	}
+	for _, r := range results {
+		switch r.Status() {
+		case scan.StatusFound:
+			us.Found++
+		case scan.StatusError:
+			us.Errors++
+		}
+	}
+	return us
+}
+
+// WriteRunSummary writes the summary to path as indented JSON.
+func WriteRunSummary(path string, s RunSummary) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
@@ -0,0 +1,137 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
+// attempts describes how a request went out.
+type attempts struct {
+	sent   int           // requests sent, including retries
+	waited time.Duration // time spent on rate limiting and backoff
+}
+
+// do sends req once the per-host rate limit allows it, retrying transient
+// network errors, 429 and 5xx responses with exponential backoff and jitter.
+// It returns the last response or error.
+func (s *Scanner) do(client *http.Client, req *http.Request, sd data.SiteData) (*http.Response, attempts, error) {
+	ctx := req.Context()
+	var a attempts
+
+	for {
+		// Every attempt is rate limited and gets a fresh copy of the request body.
+		start := time.Now()
+		err := s.limiter.Wait(ctx, req.URL.Host, sd.RateLimit)
+		a.waited += time.Since(start)
+		if err != nil {
+			return nil, a, err
+		}
+		r, err := cloneRequest(req)
+		if err != nil {
+			return nil, a, err
+		}
+
+		a.sent++
+		resp, err := client.Do(r)
+		if a.sent > s.cfg.Retries || !retryable(ctx, resp, err) {
+			return resp, a, err
+		}
+
+		delay := s.backoff(a.sent, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, s.cfg.MaxBodyBytes))
+			_ = resp.Body.Close()
+		}
+
+		start = time.Now()
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			a.waited += time.Since(start)
+			return nil, a, ctx.Err()
+		case <-timer.C:
+			a.waited += time.Since(start)
+		}
+	}
+}
+
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// retryable reports whether a failed attempt is worth repeating. Nothing is
+// retried once ctx is done.
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return transientError(err)
+	}
+	code := resp.StatusCode
+	return code == http.StatusTooManyRequests ||
+		(code >= 500 && code <= 599 && code != http.StatusNotImplemented)
+}
+
+// transientError reports whether a transport error may go away on its own.
+// DNS failures, TLS verification errors and the like are final.
+func transientError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff returns the delay before the next attempt: the response's
+// Retry-After if it has one, otherwise RetryBackoff doubled per attempt with
+// jitter. Both are capped at RetryMaxBackoff.
+func (s *Scanner) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(d, s.cfg.RetryMaxBackoff)
+		}
+	}
+
+	d := s.cfg.RetryBackoff
+	for i := 1; i < attempt && d < s.cfg.RetryMaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, s.cfg.RetryMaxBackoff)
+	// Jitter over the upper half keeps concurrent retries from lining up.
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,190 @@
+package scan
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func urlError(err error) error {
+	return &url.Error{Op: "Get", URL: "https://example.com/alice", Err: err}
+}
+
+func TestRetryable(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		err    error
+		want   bool
+	}{
+		{"ok", context.Background(), 200, nil, false},
+		{"not found", context.Background(), 404, nil, false},
+		{"too many requests", context.Background(), 429, nil, true},
+		{"server error", context.Background(), 500, nil, true},
+		{"bad gateway", context.Background(), 502, nil, true},
+		{"not implemented", context.Background(), 501, nil, false},
+		{"timeout", context.Background(), 0, urlError(timeoutError{}), true},
+		{"connection refused", context.Background(), 0, urlError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), true},
+		{"connection reset", context.Background(), 0, urlError(&net.OpError{Op: "read", Err: syscall.ECONNRESET}), true},
+		{"unexpected EOF", context.Background(), 0, urlError(io.ErrUnexpectedEOF), true},
+		{"NXDOMAIN", context.Background(), 0, urlError(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}), false},
+		{"x509", context.Background(), 0, urlError(x509.UnknownAuthorityError{}), false},
+		{"unsupported scheme", context.Background(), 0, urlError(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"redirect limit", context.Background(), 0, urlError(errors.New("stopped after 10 redirects")), false},
+		{"cancelled", cancelled, 0, urlError(context.Canceled), false},
+		{"cancelled 503", cancelled, 503, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			if got := retryable(tt.ctx, resp, tt.err); got != tt.want {
+				t.Errorf("retryable = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"3", 3 * time.Second, true},
+		{"-5", 0, true},
+		{"soon", 0, false},
+		{"Thu, 01 Jan 1970 00:00:00 GMT", 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("retryAfter(%q) = %s, %t; want %s, %t", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got, ok := retryAfter(future); !ok || got < 58*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %s, %t; want about a minute", future, got, ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := NewScanner(http.DefaultClient, Config{RetryBackoff: time.Second, RetryMaxBackoff: 5 * time.Second}, nil)
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{4, 2500 * time.Millisecond, 5 * time.Second}, // capped
+		{60, 2500 * time.Millisecond, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if d := s.backoff(tt.attempt, nil); d < tt.min || d > tt.max {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+	if d := s.backoff(1, resp); d != 2*time.Second {
+		t.Errorf("backoff with Retry-After: 2 = %s, want 2s", d)
+	}
+	resp.Header.Set("Retry-After", "3600")
+	if d := s.backoff(1, resp); d != 5*time.Second {
+		t.Errorf("backoff with Retry-After: 3600 = %s, want the 5s cap", d)
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"user":"alice"}` {
+			t.Errorf("attempt %d: body = %q", calls, body)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s := NewScanner(srv.Client(), Config{Retries: 2, RetryBackoff: time.Millisecond}, nil)
+	sd := data.SiteData{
+		ErrorType:      "status_code",
+		URL:            srv.URL + "/{}",
+		RequestMethod:  "POST",
+		RequestPayload: map[string]any{"user": "{}"},
+	}
+	res := s.Investigo(context.Background(), "alice", "Example", sd)
+	if !res.Exists || res.Attempts != 3 {
+		t.Errorf("exists=%t attempts=%d, want found after 3 attempts (err %v)", res.Exists, res.Attempts, res.Err)
+	}
+
+	calls = 0
+	s = NewScanner(srv.Client(), Config{Retries: 1, RetryBackoff: time.Millisecond}, nil)
+	res = s.Investigo(context.Background(), "alice", "Example", sd)
+	if res.Exists || res.Attempts != 2 || res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("exists=%t attempts=%d status=%d, want the last 503 after 2 attempts",
+			res.Exists, res.Attempts, res.StatusCode)
+	}
+}
+
+func TestDoDoesNotRetryPermanentErrors(t *testing.T) {
+	s := NewScanner(http.DefaultClient, Config{Retries: 3, RetryBackoff: time.Millisecond}, nil)
+	sd := data.SiteData{ErrorType: "status_code", URL: "ftp://example.com/{}"}
+	res := s.Investigo(context.Background(), "alice", "Example", sd)
+	if res.Err == nil || res.Attempts != 1 {
+		t.Errorf("err=%v attempts=%d, want a single failed attempt", res.Err, res.Attempts)
+	}
+}
+
+func TestElapsedExcludesWaiting(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s := NewScanner(srv.Client(), Config{RateLimit: 5, RateBurst: 1}, nil)
+	sd := data.SiteData{ErrorType: "status_code", URL: srv.URL + "/{}"}
+
+	_ = s.Investigo(context.Background(), "alice", "Example", sd)
+	res := s.Investigo(context.Background(), "bob", "Example", sd)
+	if res.Waited < 150*time.Millisecond {
+		t.Errorf("waited %s, want about 200ms behind the rate limiter", res.Waited)
+	}
+	if res.Elapsed > 100*time.Millisecond {
+		t.Errorf("elapsed %s includes the rate limiter wait", res.Elapsed)
+	}
+}
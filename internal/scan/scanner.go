@@ -1,14 +1,20 @@
 package scan
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/dlclark/regexp2"
 
@@ -19,6 +25,8 @@ import (
 
 type Scanner struct {
 	client      *http.Client
+	noRedirect  *http.Client // same transport, but returns 3xx responses as-is
+	limiter     *httpx.HostLimiter
 	cfg         Config
 	downloaders map[string]downloaders.DownloaderFunc
 
@@ -31,9 +39,21 @@ func NewScanner(client *http.Client, cfg Config, dls map[string]downloaders.Down
 	if cfg.Concurrency <= 0 {
 		cfg.Concurrency = 32
 	}
+	if cfg.DownloadConcurrency <= 0 {
+		cfg.DownloadConcurrency = 4
+	}
 	if cfg.MaxBodyBytes <= 0 {
 		cfg.MaxBodyBytes = 2 << 20
 	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.RetryMaxBackoff <= 0 {
+		cfg.RetryMaxBackoff = 30 * time.Second
+	}
 	if cfg.UserAgent == "" {
 		cfg.UserAgent = httpx.DefaultUserAgent
 	}
@@ -41,8 +61,16 @@ func NewScanner(client *http.Client, cfg Config, dls map[string]downloaders.Down
 		dls = map[string]downloaders.DownloaderFunc{}
 	}
 
+	// Copy the client so response_url sites can inspect the Location header.
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
 	return &Scanner{
 		client:      client,
+		noRedirect:  &noRedirect,
+		limiter:     httpx.NewHostLimiter(cfg.RateLimit, cfg.RateBurst),
 		cfg:         cfg,
 		downloaders: dls,
 	}
@@ -56,49 +84,115 @@ func (s *Scanner) ScanUsername(
 	logger *log.Logger,
 	onResult func(Result),
 ) error {
+	return s.ScanUsernames(ctx, []Target{{
+		Username:    username,
+		Sites:       sites,
+		DownloadDir: downloadDir,
+		Logger:      logger,
+	}}, onResult)
+}
+
+// ScanUsernames investigates every (username, site) pair of targets through a
+// single worker pool. Pairs are scheduled round-robin across targets, and each
+// target starts at a different point of its site list, so one user's slow
+// sites don't stall the others and the same host isn't hit by every user at once.
+// Results of all targets are delivered to onResult from a single goroutine.
+func (s *Scanner) ScanUsernames(ctx context.Context, targets []Target, onResult func(Result)) error {
 	if onResult == nil {
 		return fmt.Errorf("onResult callback is nil")
 	}
 
-	siteNames := make([]string, 0, len(sites))
-	for name := range sites {
-		siteNames = append(siteNames, name)
+	type job struct {
+		target *Target
+		site   string
 	}
-	sort.Strings(siteNames)
 
-	workers := min(s.cfg.Concurrency, len(siteNames))
+	queues := make([][]string, len(targets))
+	total := 0
+	for i, t := range targets {
+		siteNames := make([]string, 0, len(t.Sites))
+		for name := range t.Sites {
+			siteNames = append(siteNames, name)
+		}
+		sort.Strings(siteNames)
+
+		if n := len(siteNames); n > 0 {
+			offset := i * n / len(targets)
+			siteNames = append(siteNames[offset:], siteNames[:offset]...)
+		}
+		queues[i] = siteNames
+		total += len(siteNames)
+	}
+
+	workers := min(s.cfg.Concurrency, total)
 	if workers == 0 {
 		return nil
 	}
 
-	jobs := make(chan string) // Channel of site names to investigate.
+	jobs := make(chan job) // Channel of (target, site) pairs to investigate.
 	results := make(chan Result, workers)
 
+	// Downloads run in their own bounded pool so slow transfers don't stall probes.
+	// A found result is delivered once its download has finished.
+	var dlWG sync.WaitGroup
+	dlSem := make(chan struct{}, s.cfg.DownloadConcurrency)
+
 	var wg sync.WaitGroup
 	wg.Add(workers)
 	for range workers {
 		// Worker goroutines.
 		go func() {
 			defer wg.Done()
-			for site := range jobs {
-				results <- s.Investigo(ctx, username, site, sites[site], downloadDir, logger)
+			for j := range jobs {
+				t := j.target
+				res := s.Investigo(ctx, t.Username, j.site, t.Sites[j.site])
+
+				dl, ok := s.downloaderFor(res, t.DownloadDir)
+				if !ok {
+					results <- res
+					continue
+				}
+
+				dlWG.Add(1)
+				go func() {
+					defer dlWG.Done()
+					select {
+					case <-ctx.Done():
+						res.Download = &Download{Err: ctx.Err()}
+					case dlSem <- struct{}{}:
+						res.Download = s.download(ctx, dl, res, t.DownloadDir, t.Logger)
+						<-dlSem
+					}
+					results <- res
+				}()
 			}
 		}()
 	}
 
-	// Wait for workers to finish and close results channel when done.
+	// Wait for workers and downloads to finish and close results channel when done.
 	go func() {
 		defer close(results)
 		wg.Wait()
+		dlWG.Wait()
 	}()
 
 	go func() {
 		defer close(jobs)
-		for _, site := range siteNames {
-			select {
-			case <-ctx.Done():
+		for round := 0; ; round++ {
+			sent := false
+			for i := range targets {
+				if round >= len(queues[i]) {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case jobs <- job{target: &targets[i], site: queues[i][round]}:
+				}
+				sent = true
+			}
+			if !sent {
 				return
-			case jobs <- site:
 			}
 		}
 	}()
@@ -110,13 +204,34 @@ func (s *Scanner) ScanUsername(
 	return ctx.Err()
 }
 
+// downloaderFor returns the registered downloader for a found result, if downloads are enabled.
+func (s *Scanner) downloaderFor(res Result, downloadDir string) (downloaders.DownloaderFunc, bool) {
+	if !s.cfg.Download || downloadDir == "" || !res.Exists {
+		return nil, false
+	}
+	dl, ok := s.downloaders[strings.ToLower(res.Site)]
+	return dl, ok
+}
+
+func (s *Scanner) download(
+	ctx context.Context,
+	dl downloaders.DownloaderFunc,
+	res Result,
+	downloadDir string,
+	logger *log.Logger,
+) *Download {
+	out := &Download{Dir: filepath.Join(downloadDir, strings.ToLower(res.Site))}
+	out.Err = dl(ctx, s.client, res.Link, out.Dir, logger)
+	return out
+}
+
 func (s *Scanner) ValidateSites(
 	ctx context.Context,
 	sites map[string]data.SiteData,
-	onFailure func(ValidationFailure),
+	onResult func(Validation),
 ) (int, error) {
-	if onFailure == nil {
-		return 0, fmt.Errorf("onFailure callback is nil")
+	if onResult == nil {
+		return 0, fmt.Errorf("onResult callback is nil")
 	}
 
 	siteNames := make([]string, 0, len(sites))
@@ -131,7 +246,7 @@ func (s *Scanner) ValidateSites(
 	}
 
 	jobs := make(chan string)
-	failures := make(chan ValidationFailure, workers)
+	validations := make(chan Validation, workers)
 
 	var wg sync.WaitGroup
 	wg.Add(workers)
@@ -140,33 +255,27 @@ func (s *Scanner) ValidateSites(
 			defer wg.Done()
 			for site := range jobs {
 				sd := sites[site]
-				if sd.UsedUsername == "" || sd.UnusedUsername == "" {
+				unusedName, err := s.unclaimedUsername(site, sd)
+				if err == nil && sd.UsedUsername == "" {
+					err = fmt.Errorf("missing username_claimed in database")
+				}
+				if err != nil {
 					// If we can't validate, count as failure but include an error message for clarity.
-					f := ValidationFailure{
+					validations <- Validation{
 						Site:           site,
 						UsedUsername:   sd.UsedUsername,
-						UnusedUsername: sd.UnusedUsername,
-						Used: Result{
-							Username: sd.UsedUsername, Site: site, Proxied: s.cfg.WithTor,
-							Err: fmt.Errorf("missing username_claimed/username_unclaimed in database"),
-						},
-						Unused: Result{
-							Username: sd.UnusedUsername, Site: site, Proxied: s.cfg.WithTor,
-							Err: fmt.Errorf("missing username_claimed/username_unclaimed in database"),
-						},
+						UnusedUsername: unusedName,
+						Used:           Result{Username: sd.UsedUsername, Site: site, Proxied: s.cfg.WithTor, Err: err},
+						Unused:         Result{Username: unusedName, Site: site, Proxied: s.cfg.WithTor, Err: err},
 					}
-					failures <- f
 					continue
 				}
+				sd.UnusedUsername = unusedName
 
-				used := s.Investigo(ctx, sd.UsedUsername, site, sd, "", nil)
-				unused := s.Investigo(ctx, sd.UnusedUsername, site, sd, "", nil)
+				used := s.Investigo(ctx, sd.UsedUsername, site, sd)
+				unused := s.Investigo(ctx, sd.UnusedUsername, site, sd)
 
-				if used.Exists && !unused.Exists {
-					continue
-				}
-
-				failures <- ValidationFailure{
+				validations <- Validation{
 					Site:           site,
 					UsedUsername:   sd.UsedUsername,
 					UnusedUsername: sd.UnusedUsername,
@@ -178,7 +287,7 @@ func (s *Scanner) ValidateSites(
 	}
 
 	go func() {
-		defer close(failures)
+		defer close(validations)
 		wg.Wait()
 	}()
 
@@ -193,29 +302,35 @@ func (s *Scanner) ValidateSites(
 		}
 	}()
 
-	count := 0
-	for f := range failures {
-		count++
-		onFailure(f)
+	failed := 0
+	for v := range validations {
+		if v.Health() != HealthPassing {
+			failed++
+		}
+		onResult(v)
 	}
 
-	return count, ctx.Err()
+	return failed, ctx.Err()
 }
 
-func (s *Scanner) Investigo(
-	ctx context.Context,
-	username string,
-	site string,
-	sd data.SiteData,
-	downloadDir string,
-	logger *log.Logger,
-) Result {
+// Investigo checks whether username exists on site.
+func (s *Scanner) Investigo(ctx context.Context, username, site string, sd data.SiteData) Result {
+	start := time.Now()
+	res := s.investigo(ctx, username, site, sd)
+	// Queueing behind the rate limiter and retry backoff isn't response time.
+	res.Elapsed = time.Since(start) - res.Waited
+	return res
+}
+
+func (s *Scanner) investigo(ctx context.Context, username, site string, sd data.SiteData) Result {
 	res := Result{
 		Username:      username,
 		Site:          site,
 		URLTemplate:   sd.URL,
 		ProbeTemplate: sd.URLProbe,
 		Proxied:       s.cfg.WithTor,
+		NSFW:          sd.IsNSFW,
+		Category:      sd.Category,
 	}
 
 	if sd.URL == "" {
@@ -223,11 +338,7 @@ func (s *Scanner) Investigo(
 		return res
 	}
 
-	profileURL := strings.ReplaceAll(sd.URL, "{}", username)
-	probeURL := profileURL
-	if sd.URLProbe != "" {
-		probeURL = strings.ReplaceAll(sd.URLProbe, "{}", username)
-	}
+	profileURL, probeURL := probeURLs(username, sd)
 
 	// Optional username regexCheck (cached per site).
 	if sd.RegexCheck != "" {
@@ -243,32 +354,50 @@ func (s *Scanner) Investigo(
 		}
 		if !ok {
 			// Username not valid for this site => treat as not found (no error).
+			res.Skipped = true
+			res.Rule = "regexCheck: username rejected"
 			return res
 		}
 	}
 
-	req, err := httpx.NewRequest(ctx, http.MethodGet, probeURL, nil, s.cfg.UserAgent)
+	req, err := s.newProbeRequest(ctx, username, probeURL, sd)
 	if err != nil {
 		res.Err = err
 		return res
 	}
 
-	resp, err := s.client.Do(req)
+	client := s.client
+	if sd.ErrorType == "response_url" && s.cfg.NoFollowRedirects {
+		client = s.noRedirect
+	}
+
+	resp, a, err := s.do(client, req, sd)
+	res.Attempts, res.Waited = a.sent, a.waited
 	if err != nil {
 		res.Err = err
 		return res
 	}
 	defer resp.Body.Close()
 
+	res.StatusCode = resp.StatusCode
+	res.FinalURL = redirectTarget(resp)
+
 	switch sd.ErrorType {
 	case "status_code":
-		if resp.StatusCode == http.StatusOK {
+		// Codes listed in errorCode mean "not found"; any other 2xx/3xx is a hit.
+		switch {
+		case sd.ErrorCode.Contains(resp.StatusCode):
+			res.Rule = fmt.Sprintf("status_code: %d listed in errorCode", resp.StatusCode)
+		case resp.StatusCode >= 200 && resp.StatusCode < 400:
+			res.Rule = fmt.Sprintf("status_code: %d is 2xx/3xx", resp.StatusCode)
 			res.Exists = true
-			res.Link = profileURL
+		default:
+			res.Rule = fmt.Sprintf("status_code: %d is not 2xx/3xx", resp.StatusCode)
 		}
 
 	case "message":
 		body, err := s.readBody(resp.Body)
+		res.BodyBytes = int64(len(body))
 		if err != nil {
 			res.Err = err
 			return res
@@ -281,19 +410,28 @@ func (s *Scanner) Investigo(
 		}
 
 		res.Exists = !notFound
-		if res.Exists {
-			res.Link = profileURL
+		if notFound {
+			res.Rule = "message: errorMsg found in body"
+		} else {
+			res.Rule = "message: errorMsg absent from body"
 		}
 
 	case "response_url":
-		finalURL := ""
-		if resp.Request != nil && resp.Request.URL != nil {
-			finalURL = resp.Request.URL.String()
-		}
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 400 && finalURL == profileURL {
+		errorURL := strings.ReplaceAll(sd.URLError, "{}", username)
+
+		switch {
+		case resp.StatusCode < 200 || resp.StatusCode >= 400:
+			res.Rule = fmt.Sprintf("response_url: %d is not 2xx/3xx", resp.StatusCode)
+		case errorURL != "" && !sameURL(errorURL, probeURL) && matchesErrorURL(res.FinalURL, errorURL):
+			res.Rule = "response_url: redirected to errorUrl"
+		case sameURL(res.FinalURL, probeURL) || sameURL(res.FinalURL, profileURL):
+			res.Rule = "response_url: landed on profile URL"
+			res.Exists = true
+		case localeRedirect(res.FinalURL, probeURL) || localeRedirect(res.FinalURL, profileURL):
+			res.Rule = "response_url: landed on localized profile URL"
 			res.Exists = true
-			res.Link = profileURL
+		default:
+			res.Rule = "response_url: redirected away from profile URL"
 		}
 
 	default:
@@ -301,9 +439,60 @@ func (s *Scanner) Investigo(
 		return res
 	}
 
+	if res.Exists {
+		res.Link = profileURL
+	}
+	if sd.ErrorType != "message" {
+		// Drain (bounded) for the size report; this also lets the connection be reused.
+		res.BodyBytes, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, s.cfg.MaxBodyBytes))
+	}
+
 	return res
 }
 
+// newProbeRequest builds the request for probeURL using the site's method, payload and headers.
+func (s *Scanner) newProbeRequest(ctx context.Context, username, probeURL string, sd data.SiteData) (*http.Request, error) {
+	method := http.MethodGet
+	if sd.RequestMethod != "" {
+		method = strings.ToUpper(sd.RequestMethod)
+	}
+
+	var body io.Reader
+	if sd.RequestPayload != nil {
+		payload, err := json.Marshal(interpolate(sd.RequestPayload, username))
+		if err != nil {
+			return nil, fmt.Errorf("encode request_payload: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := httpx.NewRequest(ctx, method, probeURL, body, s.cfg.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(sd.Headers) > 0 {
+		headers := make(map[string]string, len(sd.Headers))
+		for k, v := range sd.Headers {
+			headers[k] = strings.ReplaceAll(v, "{}", username)
+		}
+		httpx.SetHeaders(req, headers)
+	}
+	return req, nil
+}
+
+// probeURLs returns the public profile URL and the URL actually requested.
+func probeURLs(username string, sd data.SiteData) (profileURL, probeURL string) {
+	profileURL = strings.ReplaceAll(sd.URL, "{}", username)
+	probeURL = profileURL
+	if sd.URLProbe != "" {
+		probeURL = strings.ReplaceAll(sd.URLProbe, "{}", username)
+	}
+	return profileURL, probeURL
+}
+
 func (s *Scanner) getRegex(site, expr string) (*regexp2.Regexp, error) {
 	if v, ok := s.regexCache.Load(site); ok {
 		return v.(*regexp2.Regexp), nil
@@ -330,6 +519,105 @@ func (s *Scanner) readBody(r io.Reader) (string, error) {
 	return string(b), nil
 }
 
+// redirectTarget returns where the response points to: the Location header
+// of an unfollowed redirect, or otherwise the final request URL.
+func redirectTarget(resp *http.Response) string {
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if loc, err := resp.Location(); err == nil {
+			return loc.String()
+		}
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		return resp.Request.URL.String()
+	}
+	return ""
+}
+
+// matchesErrorURL reports whether target is the errorUrl page. Database entries
+// without a scheme (e.g. "wordpress.com/typo/?subdomain=") are matched as substrings.
+func matchesErrorURL(target, errorURL string) bool {
+	if !strings.Contains(errorURL, "://") {
+		return strings.Contains(strings.ToLower(target), strings.ToLower(errorURL))
+	}
+	t, err1 := url.Parse(target)
+	e, err2 := url.Parse(errorURL)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	if !sameHostPath(t, e) {
+		return false
+	}
+	// Only compare queries when errorUrl has one; error pages often append their own.
+	return e.RawQuery == "" || t.Query().Encode() == e.Query().Encode()
+}
+
+// sameURL compares two URLs ignoring scheme, host and path case, a trailing
+// slash, default ports, query order and fragments.
+func sameURL(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ua, err1 := url.Parse(a)
+	ub, err2 := url.Parse(b)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return sameHostPath(ua, ub) && ua.Query().Encode() == ub.Query().Encode()
+}
+
+var localeSegment = regexp.MustCompile(`(?i)^[a-z]{2}([-_][a-z]{2,4})?$`)
+
+// localeRedirect reports whether target is profile on the same host with a
+// language segment prepended to the path, e.g. /alice -> /en-us/alice.
+func localeRedirect(target, profile string) bool {
+	t, err1 := url.Parse(target)
+	p, err2 := url.Parse(profile)
+	if err1 != nil || err2 != nil || normalizeHost(t) != normalizeHost(p) {
+		return false
+	}
+	locale, rest, ok := strings.Cut(strings.TrimPrefix(t.Path, "/"), "/")
+	if !ok || !localeSegment.MatchString(locale) {
+		return false
+	}
+	return strings.EqualFold(strings.TrimRight("/"+rest, "/"), strings.TrimRight(p.Path, "/"))
+}
+
+func sameHostPath(a, b *url.URL) bool {
+	return normalizeHost(a) == normalizeHost(b) &&
+		strings.EqualFold(strings.TrimRight(a.Path, "/"), strings.TrimRight(b.Path, "/"))
+}
+
+func normalizeHost(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" || (port == "80" && u.Scheme == "http") || (port == "443" && u.Scheme == "https") {
+		return host
+	}
+	return host + ":" + port
+}
+
+// interpolate replaces "{}" with username in every string of a decoded JSON value.
+func interpolate(v any, username string) any {
+	switch t := v.(type) {
+	case string:
+		return strings.ReplaceAll(t, "{}", username)
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, it := range t {
+			out[k] = interpolate(it, username)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, it := range t {
+			out[i] = interpolate(it, username)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 func containsErrorMessage(body string, errorMsg any) (bool, error) {
 	switch v := errorMsg.(type) {
 	case nil:
@@ -0,0 +1,220 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
+func TestSameURL(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://example.com/alice", "https://example.com/alice", true},
+		{"https://example.com/alice/", "https://example.com/alice", true},
+		{"https://EXAMPLE.com/Alice", "https://example.com/alice", true},
+		{"https://example.com:443/alice", "https://example.com/alice", true},
+		{"http://example.com:80/alice", "http://example.com/alice", true},
+		{"https://example.com/alice?b=2&a=1", "https://example.com/alice?a=1&b=2", true},
+		{"https://example.com/alice#top", "https://example.com/alice", true},
+		{"https://example.com:8443/alice", "https://example.com/alice", false},
+		{"https://example.com/alice?a=1", "https://example.com/alice", false},
+		{"https://example.com/login", "https://example.com/alice", false},
+		{"https://other.com/alice", "https://example.com/alice", false},
+	}
+	for _, tt := range tests {
+		if got := sameURL(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameURL(%q, %q) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesErrorURL(t *testing.T) {
+	tests := []struct {
+		target, errorURL string
+		want             bool
+	}{
+		{"https://example.com/404", "https://example.com/404", true},
+		{"https://example.com/404/", "https://EXAMPLE.com/404", true},
+		{"https://example.com/404?from=alice", "https://example.com/404", true},
+		{"https://example.com/search?q=alice", "https://example.com/search?q=alice", true},
+		{"https://example.com/search?q=bob", "https://example.com/search?q=alice", false},
+		{"https://example.com/login", "https://example.com/404", false},
+		{"https://alice.wordpress.com/typo/?subdomain=alice", "wordpress.com/typo/?subdomain=", true},
+		{"https://wordpress.com/alice", "wordpress.com/typo/?subdomain=", false},
+	}
+	for _, tt := range tests {
+		if got := matchesErrorURL(tt.target, tt.errorURL); got != tt.want {
+			t.Errorf("matchesErrorURL(%q, %q) = %t, want %t", tt.target, tt.errorURL, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleRedirect(t *testing.T) {
+	tests := []struct {
+		target, profile string
+		want            bool
+	}{
+		{"https://example.com/en/alice", "https://example.com/alice", true},
+		{"https://example.com/pt-BR/alice/", "https://example.com/alice", true},
+		{"https://example.com/login/alice", "https://example.com/alice", false},
+		{"https://example.com/en/bob", "https://example.com/alice", false},
+		{"https://other.com/en/alice", "https://example.com/alice", false},
+		{"https://example.com/alice", "https://example.com/alice", false},
+	}
+	for _, tt := range tests {
+		if got := localeRedirect(tt.target, tt.profile); got != tt.want {
+			t.Errorf("localeRedirect(%q, %q) = %t, want %t", tt.target, tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestInvestigoResponseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/u/alice", "/en/u/carol", "/404", "/login":
+			w.WriteHeader(http.StatusOK)
+		case "/u/carol":
+			http.Redirect(w, r, "/en/u/carol", http.StatusFound)
+		case "/u/dave":
+			http.Redirect(w, r, "/404", http.StatusFound)
+		default:
+			http.Redirect(w, r, "/login", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	sd := data.SiteData{
+		ErrorType: "response_url",
+		URL:       srv.URL + "/u/{}",
+		URLError:  srv.URL + "/404",
+	}
+
+	tests := []struct {
+		username   string
+		noRedirect bool
+		want       bool
+	}{
+		{"alice", false, true},
+		{"carol", false, true}, // locale redirect
+		{"dave", false, false}, // redirected to errorUrl
+		{"bob", false, false},  // redirected to an unrelated page
+		{"bob", true, false},   // same, inspecting Location
+		{"alice", true, true},
+	}
+	for _, tt := range tests {
+		s := NewScanner(srv.Client(), Config{NoFollowRedirects: tt.noRedirect}, nil)
+		res := s.Investigo(context.Background(), tt.username, "Example", sd)
+		if res.Err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.username, res.Err)
+		}
+		if res.Exists != tt.want {
+			t.Errorf("%s (noRedirect=%t): exists=%t, want %t (rule %q, final %q)",
+				tt.username, tt.noRedirect, res.Exists, tt.want, res.Rule, res.FinalURL)
+		}
+	}
+}
+
+// countingServer answers 200 for every path and records the order of requests.
+type countingServer struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	hits []string
+}
+
+func newCountingServer(t *testing.T) *countingServer {
+	t.Helper()
+	cs := &countingServer{}
+	cs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cs.mu.Lock()
+		cs.hits = append(cs.hits, r.URL.Path)
+		cs.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(cs.Close)
+	return cs
+}
+
+func (cs *countingServer) targets(usernames []string, sites int) []Target {
+	sds := make(map[string]data.SiteData, sites)
+	for i := range sites {
+		name := fmt.Sprintf("Site%02d", i)
+		sds[name] = data.SiteData{ErrorType: "status_code", URL: cs.URL + "/" + name + "/{}"}
+	}
+	targets := make([]Target, len(usernames))
+	for i, username := range usernames {
+		targets[i] = Target{Username: username, Sites: sds}
+	}
+	return targets
+}
+
+func TestScanUsernames(t *testing.T) {
+	usernames := []string{"alice", "bob", "carol"}
+	const sites = 7
+
+	for _, concurrency := range []int{1, 4, 64} {
+		t.Run(fmt.Sprint(concurrency), func(t *testing.T) {
+			srv := newCountingServer(t)
+			s := NewScanner(srv.Client(), Config{Concurrency: concurrency}, nil)
+
+			delivered := make(map[string]int)
+			err := s.ScanUsernames(context.Background(), srv.targets(usernames, sites), func(res Result) {
+				if res.Err != nil || !res.Exists {
+					t.Errorf("%s on %s: exists=%t err=%v", res.Username, res.Site, res.Exists, res.Err)
+				}
+				delivered[res.Username+"/"+res.Site]++
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(delivered) != len(usernames)*sites || len(srv.hits) != len(usernames)*sites {
+				t.Errorf("delivered %d pairs after %d requests, want %d", len(delivered), len(srv.hits), len(usernames)*sites)
+			}
+			for pair, n := range delivered {
+				if n != 1 {
+					t.Errorf("%s delivered %d times", pair, n)
+				}
+			}
+
+			if concurrency == 1 {
+				// Each target starts at its own offset into the site list.
+				want := []string{"/Site00/alice", "/Site02/bob", "/Site04/carol", "/Site01/alice"}
+				if got := srv.hits[:len(want)]; strings.Join(got, " ") != strings.Join(want, " ") {
+					t.Errorf("first requests = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScanUsernamesCancel(t *testing.T) {
+	srv := newCountingServer(t)
+	s := NewScanner(srv.Client(), Config{Concurrency: 1}, nil)
+	targets := srv.targets([]string{"alice", "bob", "carol"}, 20)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	delivered := 0
+	err := s.ScanUsernames(ctx, targets, func(res Result) {
+		delivered++
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if delivered >= 60 || len(srv.hits) > 2 {
+		t.Errorf("after cancelling: %d results delivered, %d requests sent; want the feed to stop", delivered, len(srv.hits))
+	}
+}
@@ -0,0 +1,256 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
+// PatchOp is a single RFC 6902 JSON Patch operation against the database.
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// Suggestion is a proposed database repair for a failing site.
+// Patch is empty when no fix could be inferred; Reason explains why.
+type Suggestion struct {
+	Site   string
+	Reason string
+	Patch  []PatchOp
+}
+
+type probeResponse struct {
+	username string
+	status   int
+	finalURL string
+	body     string
+}
+
+// SuggestFixes re-probes failing sites with the claimed and unclaimed usernames
+// and infers a corrected errorType/errorMsg/errorCode/errorUrl for each.
+// Suggestions are delivered sorted by site name.
+func (s *Scanner) SuggestFixes(
+	ctx context.Context,
+	sites map[string]data.SiteData,
+	failing []Validation,
+	onSuggestion func(Suggestion),
+) error {
+	if onSuggestion == nil {
+		return fmt.Errorf("onSuggestion callback is nil")
+	}
+
+	workers := min(s.cfg.Concurrency, len(failing))
+	if workers == 0 {
+		return nil
+	}
+
+	jobs := make(chan Validation)
+	var (
+		mu          sync.Mutex
+		suggestions []Suggestion
+		wg          sync.WaitGroup
+	)
+
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for v := range jobs {
+				sug := s.suggest(ctx, v.Site, sites[v.Site], v.UsedUsername, v.UnusedUsername)
+				mu.Lock()
+				suggestions = append(suggestions, sug)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	func() {
+		defer close(jobs)
+		for _, v := range failing {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- v:
+			}
+		}
+	}()
+	wg.Wait()
+
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Site < suggestions[j].Site })
+	for _, sug := range suggestions {
+		onSuggestion(sug)
+	}
+	return ctx.Err()
+}
+
+func (s *Scanner) suggest(ctx context.Context, site string, sd data.SiteData, claimed, unclaimed string) Suggestion {
+	sug := Suggestion{Site: site}
+
+	if sd.URL == "" || claimed == "" || unclaimed == "" {
+		sug.Reason = "site has no url or usernames to compare"
+		return sug
+	}
+
+	c, err := s.fetchProbe(ctx, claimed, sd)
+	if err != nil {
+		sug.Reason = "claimed probe failed: " + err.Error()
+		return sug
+	}
+	u, err := s.fetchProbe(ctx, unclaimed, sd)
+	if err != nil {
+		sug.Reason = "unclaimed probe failed: " + err.Error()
+		return sug
+	}
+
+	patch := patchBuilder{site: site, sd: sd}
+	cOK := c.status >= 200 && c.status < 400
+	uOK := u.status >= 200 && u.status < 400
+	_, claimedProbe := probeURLs(claimed, sd)
+	_, unclaimedProbe := probeURLs(unclaimed, sd)
+
+	switch {
+	case !cOK:
+		sug.Reason = fmt.Sprintf("claimed username returned HTTP %d; the site may block automated requests or the claimed username is gone", c.status)
+		return sug
+
+	case throttled(u.status):
+		// A rate limit or server error says nothing about the username.
+		sug.Reason = fmt.Sprintf("inconclusive: unclaimed username returned HTTP %d; the site may be rate limiting or failing", u.status)
+		return sug
+
+	case c.status != u.status:
+		patch.set("errorType", "status_code")
+		if uOK {
+			// Both look successful, so the miss status has to be listed explicitly.
+			patch.set("errorCode", u.status)
+		} else {
+			patch.remove("errorCode")
+		}
+		patch.remove("errorMsg")
+		patch.remove("errorUrl")
+		sug.Reason = fmt.Sprintf("status differs: claimed %d, unclaimed %d", c.status, u.status)
+
+	case sameURL(c.finalURL, claimedProbe) && !sameURL(u.finalURL, unclaimedProbe):
+		errorURL := strings.ReplaceAll(u.finalURL, unclaimed, "{}")
+		patch.set("errorType", "response_url")
+		patch.set("errorUrl", errorURL)
+		patch.remove("errorMsg")
+		patch.remove("errorCode")
+		sug.Reason = fmt.Sprintf("unclaimed username redirects to %s", u.finalURL)
+
+	default:
+		marker := distinguishingMarker(c, u)
+		if marker == "" {
+			sug.Reason = fmt.Sprintf("claimed and unclaimed responses are indistinguishable (HTTP %d)", c.status)
+			return sug
+		}
+		patch.set("errorType", "message")
+		patch.set("errorMsg", marker)
+		patch.remove("errorCode")
+		patch.remove("errorUrl")
+		sug.Reason = fmt.Sprintf("unclaimed response contains %q", marker)
+	}
+
+	sug.Patch = patch.ops
+	return sug
+}
+
+// throttled reports whether a status reflects the site's state (rate
+// limiting, server errors) rather than the username.
+func throttled(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status <= 599)
+}
+
+// fetchProbe requests the probe URL (following redirects) and reads the body.
+func (s *Scanner) fetchProbe(ctx context.Context, username string, sd data.SiteData) (probeResponse, error) {
+	_, probeURL := probeURLs(username, sd)
+	req, err := s.newProbeRequest(ctx, username, probeURL, sd)
+	if err != nil {
+		return probeResponse{}, err
+	}
+
+	resp, _, err := s.do(s.client, req, sd)
+	if err != nil {
+		return probeResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := s.readBody(resp.Body)
+	if err != nil {
+		return probeResponse{}, err
+	}
+	return probeResponse{
+		username: username,
+		status:   resp.StatusCode,
+		finalURL: redirectTarget(resp),
+		body:     body,
+	}, nil
+}
+
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>.*?</title>`)
+
+// distinguishingMarker finds a short string present in the unclaimed body but
+// absent from the claimed one, preferring the page title. Strings mentioning
+// either username are ignored since they would not generalize.
+func distinguishingMarker(c, u probeResponse) string {
+	usable := func(m string) bool {
+		lower := strings.ToLower(m)
+		return len(m) >= 6 && len(m) <= 200 &&
+			!strings.Contains(c.body, m) &&
+			!strings.Contains(lower, strings.ToLower(c.username)) &&
+			!strings.Contains(lower, strings.ToLower(u.username))
+	}
+
+	if title := titlePattern.FindString(u.body); title != "" && usable(title) {
+		return title
+	}
+
+	for line := range strings.SplitSeq(u.body, "\n") {
+		line = strings.TrimSpace(line)
+		// Prefer human-readable text over markup or minified script.
+		if strings.Contains(line, " ") && usable(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+type patchBuilder struct {
+	site string
+	sd   data.SiteData
+	ops  []PatchOp
+}
+
+func (p *patchBuilder) set(field string, value any) {
+	p.ops = append(p.ops, PatchOp{Op: "add", Path: p.path(field), Value: value})
+}
+
+// remove only emits an operation for fields the entry currently has,
+// since RFC 6902 "remove" fails on missing members.
+func (p *patchBuilder) remove(field string) {
+	has := false
+	switch field {
+	case "errorMsg":
+		has = p.sd.ErrorMsg != nil
+	case "errorCode":
+		has = len(p.sd.ErrorCode) > 0
+	case "errorUrl":
+		has = p.sd.URLError != ""
+	}
+	if has {
+		p.ops = append(p.ops, PatchOp{Op: "remove", Path: p.path(field)})
+	}
+}
+
+func (p *patchBuilder) path(field string) string {
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	return "/" + escape.Replace(p.site) + "/" + field
+}
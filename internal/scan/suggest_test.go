@@ -0,0 +1,152 @@
+package scan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
+// suggestServer serves /<case>/<username>: alice always has a profile, while
+// nobody gets the response of the case under test.
+func suggestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scenario, username, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if username == "alice" && scenario != "blocked" {
+			_, _ = w.Write([]byte("<title>alice's profile</title>\n<p>Joined in 2019</p>"))
+			return
+		}
+		switch scenario {
+		case "status", "blocked":
+			w.WriteHeader(http.StatusNotFound)
+		case "moved":
+			w.WriteHeader(http.StatusNoContent)
+		case "throttled":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "failing":
+			w.WriteHeader(http.StatusBadGateway)
+		case "redirect":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "message":
+			_, _ = w.Write([]byte("<title>Page not found</title>\n<p>Sorry, nobody here</p>"))
+		default:
+			_, _ = w.Write([]byte("<title>alice's profile</title>\n<p>Joined in 2019</p>"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSuggest(t *testing.T) {
+	srv := suggestServer(t)
+	s := NewScanner(srv.Client(), Config{}, nil)
+
+	tests := []struct {
+		scenario string
+		sd       data.SiteData
+		reason   string
+		patch    []PatchOp
+	}{
+		{
+			scenario: "status",
+			sd:       data.SiteData{ErrorType: "message", ErrorMsg: "Not here"},
+			reason:   "status differs: claimed 200, unclaimed 404",
+			patch: []PatchOp{
+				{Op: "add", Path: "/Example/errorType", Value: "status_code"},
+				{Op: "remove", Path: "/Example/errorMsg"},
+			},
+		},
+		{
+			scenario: "moved",
+			sd:       data.SiteData{ErrorType: "status_code"},
+			reason:   "status differs: claimed 200, unclaimed 204",
+			patch: []PatchOp{
+				{Op: "add", Path: "/Example/errorType", Value: "status_code"},
+				{Op: "add", Path: "/Example/errorCode", Value: 204},
+			},
+		},
+		{
+			scenario: "throttled",
+			sd:       data.SiteData{ErrorType: "message", ErrorMsg: "Not here"},
+			reason:   "inconclusive: unclaimed username returned HTTP 429",
+		},
+		{
+			scenario: "failing",
+			sd:       data.SiteData{ErrorType: "message", ErrorMsg: "Not here"},
+			reason:   "inconclusive: unclaimed username returned HTTP 502",
+		},
+		{
+			scenario: "blocked",
+			sd:       data.SiteData{ErrorType: "status_code"},
+			reason:   "claimed username returned HTTP 404",
+		},
+		{
+			scenario: "redirect",
+			sd:       data.SiteData{ErrorType: "status_code", ErrorCode: data.StatusCodes{404}},
+			reason:   "unclaimed username redirects to " + srv.URL + "/login",
+			patch: []PatchOp{
+				{Op: "add", Path: "/Example/errorType", Value: "response_url"},
+				{Op: "add", Path: "/Example/errorUrl", Value: srv.URL + "/login"},
+				{Op: "remove", Path: "/Example/errorCode"},
+			},
+		},
+		{
+			scenario: "message",
+			sd:       data.SiteData{ErrorType: "status_code"},
+			reason:   `unclaimed response contains "<title>Page not found</title>"`,
+			patch: []PatchOp{
+				{Op: "add", Path: "/Example/errorType", Value: "message"},
+				{Op: "add", Path: "/Example/errorMsg", Value: "<title>Page not found</title>"},
+			},
+		},
+		{
+			scenario: "same",
+			sd:       data.SiteData{ErrorType: "status_code"},
+			reason:   "claimed and unclaimed responses are indistinguishable (HTTP 200)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			tt.sd.URL = srv.URL + "/" + tt.scenario + "/{}"
+			sug := s.suggest(context.Background(), "Example", tt.sd, "alice", "nobody")
+			if !strings.HasPrefix(sug.Reason, tt.reason) {
+				t.Errorf("reason = %q, want it to start with %q", sug.Reason, tt.reason)
+			}
+			if !reflect.DeepEqual(sug.Patch, tt.patch) {
+				t.Errorf("patch = %+v, want %+v", sug.Patch, tt.patch)
+			}
+		})
+	}
+}
+
+func TestDistinguishingMarker(t *testing.T) {
+	const claimed = "<title>Alice - Example</title>\n<p>Joined in 2019</p>"
+	tests := []struct {
+		name      string
+		unclaimed string
+		want      string
+	}{
+		{"title", "<title>Page not found</title>\n<p>Sorry, nothing here</p>", "<title>Page not found</title>"},
+		{"title names the username", "<title>nobody - Example</title>\n<p>Sorry, nothing here</p>", "<p>Sorry, nothing here</p>"},
+		{"title also on the claimed page", "<title>Alice - Example</title>\n<p>This account does not exist</p>", "<p>This account does not exist</p>"},
+		{"lines without spaces", "<div>\n{\"error\":true}\n<p>User was deleted</p>", "<p>User was deleted</p>"},
+		{"too short", "No go", ""},
+		{"line names the username", "<p>nobody was not found</p>", ""},
+		{"same page", claimed, ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := probeResponse{username: "alice", body: claimed}
+			u := probeResponse{username: "nobody", body: tt.unclaimed}
+			if got := distinguishingMarker(c, u); got != tt.want {
+				t.Errorf("distinguishingMarker = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
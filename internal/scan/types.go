@@ -1,5 +1,13 @@
 package scan
 
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
 type Result struct {
 	Username string
 	Site     string
@@ -10,7 +18,76 @@ type Result struct {
 
 	Exists  bool
 	Proxied bool
-	Err     error
+	NSFW    bool
+	// Category is the site's category from the database, if any.
+	Category string
+	Err      error
+
+	// Skipped is set when the site was not queried (username rejected by regexCheck).
+	Skipped bool
+	// StatusCode is the HTTP status of the probe response (0 if none).
+	StatusCode int
+	// FinalURL is where the probe ended up after redirects (or the
+	// Location of an unfollowed redirect).
+	FinalURL string
+	// BodyBytes is the number of response body bytes read (capped by MaxBodyBytes).
+	BodyBytes int64
+	// Rule describes the detection rule that decided the result.
+	Rule string
+
+	// Attempts is the number of requests sent, including retries.
+	Attempts int
+	// Elapsed is the time spent on the site's requests and responses.
+	Elapsed time.Duration
+	// Waited is the time spent on rate limiting and retry backoff, not
+	// counted in Elapsed.
+	Waited time.Duration
+
+	// Download is set when a registered downloader ran for this result.
+	Download *Download
+}
+
+// Result statuses, as reported by Result.Status.
+const (
+	StatusFound    = "found"
+	StatusNotFound = "not found"
+	StatusError    = "error"
+	StatusSkipped  = "skipped"
+)
+
+func (r Result) Status() string {
+	switch {
+	case r.Err != nil:
+		return StatusError
+	case r.Exists:
+		return StatusFound
+	case r.Skipped:
+		return StatusSkipped
+	default:
+		return StatusNotFound
+	}
+}
+
+// ProfileURL returns the found link, or the site URL the username would have.
+func (r Result) ProfileURL() string {
+	if r.Link != "" {
+		return r.Link
+	}
+	return strings.ReplaceAll(r.URLTemplate, "{}", r.Username)
+}
+
+type Download struct {
+	Dir string
+	Err error
+}
+
+// Target is a username to scan with ScanUsernames, along with the sites to
+// check and where its downloads go.
+type Target struct {
+	Username    string
+	Sites       map[string]data.SiteData
+	DownloadDir string
+	Logger      *log.Logger
 }
 
 type Config struct {
@@ -19,13 +96,55 @@ type Config struct {
 	Download     bool
 	Concurrency  int
 	MaxBodyBytes int64
+
+	// DownloadConcurrency bounds how many downloaders run at once.
+	DownloadConcurrency int
+
+	// NoFollowRedirects makes response_url sites inspect the redirect's
+	// Location header instead of following it.
+	NoFollowRedirects bool
+
+	// RateLimit is the default number of requests per second sent to a
+	// single host (0 disables limiting); sites may override it with
+	// rateLimit. RateBurst is how many requests may go out back to back.
+	RateLimit float64
+	RateBurst int
+
+	// Retries is how many times a probe is repeated after a transient network error,
+	// 429 or 5xx. Attempts back off exponentially from RetryBackoff, with
+	// jitter, up to RetryMaxBackoff (which also caps Retry-After).
+	Retries         int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
 }
 
-type ValidationFailure struct {
-	Site          string
-	UsedUsername  string
+// Site health, as reported by Validation.Health.
+const (
+	HealthPassing       = "passing"
+	HealthFalsePositive = "false-positive"
+	HealthFalseNegative = "false-negative"
+	HealthErrored       = "errored"
+)
+
+// Validation is the outcome of probing a site with a claimed and an unclaimed username.
+type Validation struct {
+	Site           string
+	UsedUsername   string
 	UnusedUsername string
 
 	Used   Result
 	Unused Result
 }
+
+func (v Validation) Health() string {
+	switch {
+	case v.Used.Err != nil || v.Unused.Err != nil:
+		return HealthErrored
+	case v.Unused.Exists:
+		return HealthFalsePositive
+	case !v.Used.Exists:
+		return HealthFalseNegative
+	default:
+		return HealthPassing
+	}
+}
@@ -0,0 +1,60 @@
+package scan
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
+const (
+	lowerLetters = "abcdefghijklmnopqrstuvwxyz"
+	digits       = "0123456789"
+)
+
+// unclaimedShapes generate candidate usernames that are very unlikely to be
+// registered. They are tried in order until one satisfies the site's regexCheck.
+var unclaimedShapes = []func() string{
+	func() string { return randomString(lowerLetters, 1) + randomString(lowerLetters+digits, 11) },
+	func() string { return randomString(lowerLetters, 10) },
+	func() string { return randomString(lowerLetters, 6) + "_" + randomString(lowerLetters, 6) },
+	func() string { return randomString(lowerLetters, 6) },
+	func() string { return strings.ToUpper(randomString(lowerLetters, 1)) + randomString(lowerLetters, 9) },
+	func() string { return randomString(digits, 9) },
+}
+
+// unclaimedUsername returns the site's username_unclaimed, or synthesizes a
+// random one that passes its regexCheck when the database doesn't provide it.
+func (s *Scanner) unclaimedUsername(site string, sd data.SiteData) (string, error) {
+	if sd.UnusedUsername != "" {
+		return sd.UnusedUsername, nil
+	}
+	if sd.RegexCheck == "" {
+		return unclaimedShapes[0](), nil
+	}
+
+	re, err := s.getRegex(site, sd.RegexCheck)
+	if err != nil {
+		return "", fmt.Errorf("invalid regexCheck: %w", err)
+	}
+
+	// A few attempts per shape, since some patterns only reject specific characters.
+	for _, shape := range unclaimedShapes {
+		for range 4 {
+			candidate := shape()
+			if ok, err := re.MatchString(candidate); err == nil && ok {
+				return candidate, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot generate an unclaimed username matching regexCheck %q", sd.RegexCheck)
+}
+
+func randomString(alphabet string, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[rand.IntN(len(alphabet))]
+	}
+	return string(b)
+}
@@ -0,0 +1,61 @@
+package scan
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tdh8316/Investigo/internal/data"
+)
+
+func TestUnclaimedUsername(t *testing.T) {
+	tests := []struct {
+		name    string
+		sd      data.SiteData
+		want    string // exact result, if fixed
+		wantErr string
+	}{
+		{name: "from database", sd: data.SiteData{UnusedUsername: "noonehere", RegexCheck: "^[0-9]+$"}, want: "noonehere"},
+		{name: "no regexCheck", sd: data.SiteData{}},
+		{name: "lowercase letters", sd: data.SiteData{RegexCheck: "^[a-z]{3,10}$"}},
+		{name: "underscore required", sd: data.SiteData{RegexCheck: "^[a-z]+_[a-z]+$"}},
+		{name: "capitalized", sd: data.SiteData{RegexCheck: "^[A-Z][a-z]+$"}},
+		{name: "digits only", sd: data.SiteData{RegexCheck: `^\d{5,12}$`}},
+		{name: "lookahead", sd: data.SiteData{RegexCheck: `^(?!\d)[a-z0-9]{12}$`}},
+		{name: "invalid", sd: data.SiteData{RegexCheck: "^[a-z"}, wantErr: "invalid regexCheck"},
+		{name: "impossible", sd: data.SiteData{RegexCheck: "^-+$"}, wantErr: "cannot generate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(http.DefaultClient, Config{}, nil)
+			got, err := s.unclaimedUsername("Example", tt.sd)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %q, err %v; want an error mentioning %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" {
+				if got != tt.want {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+				return
+			}
+			if got == "" {
+				t.Fatal("got an empty username")
+			}
+			if tt.sd.RegexCheck != "" {
+				re, err := s.getRegex("Example", tt.sd.RegexCheck)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok, _ := re.MatchString(got); !ok {
+					t.Errorf("%q does not match regexCheck %q", got, tt.sd.RegexCheck)
+				}
+			}
+		})
+	}
+}